@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"pragprog.com/rggo/firstProgram/wc/counter"
+)
+
+// langCounts holds the totals for a single language
+type langCounts struct {
+	files  int
+	counts counter.CodeCounts
+}
+
+// runCode classifies the lines of every file as code, comment or blank, reporting
+// one result per language, sorted by name, followed by a total. The language of each
+// file is detected by its extension unless cfg.lang is set. Files in an unknown
+// language are skipped
+func runCode(filenames []string, stdin bool, cfg config, in io.Reader, out, errOut io.Writer) error {
+	var forced *counter.Language
+
+	if cfg.lang != "" {
+		lang, ok := counter.LanguageByName(cfg.lang)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrInvalidLanguage, cfg.lang)
+		}
+		forced = &lang
+	}
+
+	// The Standard Input has no file name to detect the language from
+	if stdin && forced == nil {
+		return fmt.Errorf("%w: -lang is required to classify the Standard Input", ErrInvalidLanguage)
+	}
+
+	results := make([]counter.CodeCounts, len(filenames))
+	langs := make([]string, len(filenames))
+
+	errs := process(filenames, cfg, in, func(i int, r io.Reader) error {
+		lang, ok := counter.LanguageOf(filenames[i])
+		if forced != nil {
+			lang, ok = *forced, true
+		}
+
+		if !ok {
+			return nil
+		}
+
+		c, err := counter.Classify(r, lang)
+		results[i] = c
+		langs[i] = lang.Name
+		return err
+	})
+
+	byLang := map[string]*langCounts{}
+	total := langCounts{}
+
+	eachCounted(errs, errOut, func(i int) {
+		// Unknown language
+		if langs[i] == "" {
+			return
+		}
+
+		if byLang[langs[i]] == nil {
+			byLang[langs[i]] = &langCounts{}
+		}
+
+		byLang[langs[i]].files++
+		byLang[langs[i]].counts.Add(results[i])
+
+		total.files++
+		total.counts.Add(results[i])
+	})
+
+	names := make([]string, 0, len(byLang))
+	for name := range byLang {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rep := report{columns: []string{"files", "code", "comment", "blank"}}
+
+	for _, name := range names {
+		rep.rows = append(rep.rows, row{name: name, values: byLang[name].values()})
+	}
+
+	rep.total = &row{name: "total", values: total.values()}
+
+	return printResults(out, rep, cfg.format, errs)
+}
+
+// values returns the counts in the same order as the report columns
+func (lc langCounts) values() []int {
+	return []int{lc.files, lc.counts.Code, lc.counts.Comment, lc.counts.Blank}
+}
@@ -0,0 +1,229 @@
+package counter
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Language describes how comments are written in a programming language, so its
+// lines can be classified as code, comment or blank
+type Language struct {
+	Name string
+	// LineComments hold the markers starting a comment running to the end of line
+	LineComments []string
+	// BlockStart and BlockEnd delimit comments that can span several lines. They are
+	// empty if the language has no block comments
+	BlockStart string
+	BlockEnd   string
+	// Strings hold the delimiters of string literals, which are closed by the same
+	// delimiter, longest first. A backslash escapes the character after it.
+	// RawStrings work the same but without escapes
+	Strings    []string
+	RawStrings []string
+}
+
+// Languages holds the languages known by LanguageOf, by file extension
+var Languages = map[string]Language{
+	".go": {Name: "Go", LineComments: []string{"//"}, BlockStart: "/*", BlockEnd: "*/",
+		Strings: []string{`"`, "'"}, RawStrings: []string{"`"}},
+	".py": {Name: "Python", LineComments: []string{"#"}, BlockStart: `"""`, BlockEnd: `"""`,
+		Strings: []string{`"""`, "'''", `"`, "'"}},
+	".sh":       {Name: "Shell", LineComments: []string{"#"}, Strings: []string{`"`}, RawStrings: []string{"'"}},
+	".bash":     {Name: "Shell", LineComments: []string{"#"}, Strings: []string{`"`}, RawStrings: []string{"'"}},
+	".md":       {Name: "Markdown", BlockStart: "<!--", BlockEnd: "-->"},
+	".markdown": {Name: "Markdown", BlockStart: "<!--", BlockEnd: "-->"},
+}
+
+// LanguageOf returns the language of the file by its extension. The boolean is
+// false when the language is unknown
+func LanguageOf(fname string) (Language, bool) {
+	lang, ok := Languages[strings.ToLower(filepath.Ext(fname))]
+	return lang, ok
+}
+
+// LanguageByName returns the known language with the given name, ignoring case
+func LanguageByName(name string) (Language, bool) {
+	for _, lang := range Languages {
+		if strings.EqualFold(lang.Name, name) {
+			return lang, true
+		}
+	}
+
+	return Language{}, false
+}
+
+// CodeCounts holds the number of lines of each kind in source code
+type CodeCounts struct {
+	Code    int
+	Comment int
+	Blank   int
+}
+
+// Add accumulates the counts from o into c
+func (c *CodeCounts) Add(o CodeCounts) {
+	c.Code += o.Code
+	c.Comment += o.Comment
+	c.Blank += o.Blank
+}
+
+// Classify reads r until EOF classifying each line as blank, comment or code
+// according to lang. A line with both code and a comment, such as a statement
+// followed by a comment, counts as code. Comment markers inside string literals
+// are ignored, and the lines of a string literal spanning several lines count as
+// code. In Python a block comment is a docstring, a """ string starting a line.
+// Other constructs, such as shell here-documents, are not recognized
+func Classify(r io.Reader, lang Language) (CodeCounts, error) {
+	c := CodeCounts{}
+
+	scanner := newScanner(r)
+
+	// st keeps the block comment or string literal still open at the end of a line
+	st := lineState{}
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+
+		if len(line) == 0 {
+			c.Blank++
+			continue
+		}
+
+		var code bool
+		code, st = classifyLine(string(line), lang, st)
+
+		if code {
+			c.Code++
+		} else {
+			c.Comment++
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return CodeCounts{}, err
+	}
+
+	return c, nil
+}
+
+// lineState is what is still open at the end of a line
+type lineState struct {
+	// inBlock is set while inside a block comment
+	inBlock bool
+	// quote is the delimiter of the string literal the line ends in, if any, and
+	// raw is set if the string has no escapes
+	quote string
+	raw   bool
+}
+
+// classifyLine checks if the trimmed line has any code outside comments, and what
+// is still open at the end of the line
+func classifyLine(line string, lang Language, st lineState) (code bool, next lineState) {
+	for len(line) > 0 {
+		if st.inBlock {
+			end := strings.Index(line, lang.BlockEnd)
+			if end < 0 {
+				return code, st
+			}
+
+			line = strings.TrimSpace(line[end+len(lang.BlockEnd):])
+			st.inBlock = false
+			continue
+		}
+
+		if st.quote != "" {
+			// The contents of a string literal are code
+			code = true
+
+			end := stringEnd(line, st)
+			if end < 0 {
+				return code, st
+			}
+
+			line = line[end+len(st.quote):]
+			st.quote = ""
+			continue
+		}
+
+		// A block comment starting a line wins over a string with the same
+		// delimiter, such as a Python docstring
+		if !code && lang.BlockStart != "" && strings.HasPrefix(line, lang.BlockStart) {
+			line = line[len(lang.BlockStart):]
+			st.inBlock = true
+			continue
+		}
+
+		for _, lc := range lang.LineComments {
+			if strings.HasPrefix(line, lc) {
+				return code, st
+			}
+		}
+
+		if quote, raw, ok := stringStart(line, lang); ok {
+			line = line[len(quote):]
+			code = true
+			st.quote, st.raw = quote, raw
+			continue
+		}
+
+		if lang.BlockStart != "" && strings.HasPrefix(line, lang.BlockStart) {
+			line = line[len(lang.BlockStart):]
+			st.inBlock = true
+			continue
+		}
+
+		// Anything else is code. Skip to the next comment marker or string, if any
+		code = true
+		next := len(line)
+
+		markers := append([]string{lang.BlockStart}, lang.LineComments...)
+		markers = append(markers, lang.Strings...)
+		markers = append(markers, lang.RawStrings...)
+
+		for _, m := range markers {
+			if i := strings.Index(line, m); m != "" && i >= 0 && i < next {
+				next = i
+			}
+		}
+
+		line = line[next:]
+	}
+
+	return code, st
+}
+
+// stringStart checks if line starts with a string literal of lang, returning its
+// delimiter and if it's a raw string
+func stringStart(line string, lang Language) (quote string, raw bool, ok bool) {
+	for _, q := range lang.Strings {
+		if strings.HasPrefix(line, q) {
+			return q, false, true
+		}
+	}
+
+	for _, q := range lang.RawStrings {
+		if strings.HasPrefix(line, q) {
+			return q, true, true
+		}
+	}
+
+	return "", false, false
+}
+
+// stringEnd returns the index of the delimiter closing the string literal open in
+// st, skipping escaped characters, or -1 if the string doesn't end in line
+func stringEnd(line string, st lineState) int {
+	for i := 0; i < len(line); i++ {
+		if !st.raw && line[i] == '\\' {
+			i++
+			continue
+		}
+
+		if strings.HasPrefix(line[i:], st.quote) {
+			return i
+		}
+	}
+
+	return -1
+}
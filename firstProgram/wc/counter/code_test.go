@@ -0,0 +1,118 @@
+package counter_test
+
+import (
+	"bytes"
+	"testing"
+
+	"pragprog.com/rggo/firstProgram/wc/counter"
+)
+
+// TestClassify tests the Classify function for every known language
+func TestClassify(t *testing.T) {
+	testCases := []struct {
+		name  string
+		fname string
+		input string
+		exp   counter.CodeCounts
+	}{
+		{name: "Go", fname: "main.go",
+			input: "// Package main\npackage main\n\n/* block\n   comment */\nfunc main() { // comment\n\tx := 1 /* start\n*/ y := 2\n}\n",
+			exp:   counter.CodeCounts{Code: 5, Comment: 3, Blank: 1},
+		},
+		{name: "Python", fname: "script.py",
+			input: "#!/usr/bin/env python\n\"\"\"Module docstring\n\nmore\n\"\"\"\nimport os  # comment\n\n\ndef f():\n    \"\"\"One line.\"\"\"\n    return 1\n",
+			exp:   counter.CodeCounts{Code: 3, Comment: 5, Blank: 3},
+		},
+		{name: "Shell", fname: "run.sh",
+			input: "#!/bin/sh\n# comment\necho hi # comment\n\n",
+			exp:   counter.CodeCounts{Code: 1, Comment: 2, Blank: 1},
+		},
+		{name: "Markdown", fname: "README.md",
+			input: "# Title\n\n<!-- hidden\nnote -->\nText <!-- inline -->\n",
+			exp:   counter.CodeCounts{Code: 2, Comment: 2, Blank: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lang, ok := counter.LanguageOf(tc.fname)
+			if !ok {
+				t.Fatalf("Expected known language for %q.\n", tc.fname)
+			}
+
+			if lang.Name != tc.name {
+				t.Errorf("Expected language %q, got %q instead.\n", tc.name, lang.Name)
+			}
+
+			res, err := counter.Classify(bytes.NewBufferString(tc.input), lang)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res != tc.exp {
+				t.Errorf("Expected %+v, got %+v instead.\n", tc.exp, res)
+			}
+		})
+	}
+}
+
+// TestClassifyStrings tests comment markers inside string literals are not
+// taken as comments
+func TestClassifyStrings(t *testing.T) {
+	testCases := []struct {
+		name  string
+		lang  string
+		input string
+		exp   counter.CodeCounts
+	}{
+		{name: "GoBlockStart", lang: "go",
+			input: "s := \"/*\"\nx := 1\ny := 2 // */\n",
+			exp:   counter.CodeCounts{Code: 3},
+		},
+		{name: "GoEscapedQuote", lang: "go",
+			input: "s := \"\\\" // not a comment\"\nr := '\"'\n// comment\n",
+			exp:   counter.CodeCounts{Code: 2, Comment: 1},
+		},
+		{name: "GoRawString", lang: "go",
+			input: "s := `first\n/* still a string\n`\nx := 1\n",
+			exp:   counter.CodeCounts{Code: 4},
+		},
+		{name: "PythonString", lang: "python",
+			input: "x = \"\"\"\n# not a comment\n\"\"\"\ny = 1\n# comment\n",
+			exp:   counter.CodeCounts{Code: 4, Comment: 1},
+		},
+		{name: "ShellQuotes", lang: "shell",
+			input: "echo \"#1\" '#2'\n# comment\n",
+			exp:   counter.CodeCounts{Code: 1, Comment: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lang, ok := counter.LanguageByName(tc.lang)
+			if !ok {
+				t.Fatalf("Expected known language %q.\n", tc.lang)
+			}
+
+			res, err := counter.Classify(bytes.NewBufferString(tc.input), lang)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res != tc.exp {
+				t.Errorf("Expected %+v, got %+v instead.\n", tc.exp, res)
+			}
+		})
+	}
+}
+
+// TestLanguageOf tests unknown file extensions are reported
+func TestLanguageOf(t *testing.T) {
+	if _, ok := counter.LanguageOf("data.bin"); ok {
+		t.Error("Expected unknown language for data.bin.")
+	}
+
+	if lang, ok := counter.LanguageByName("python"); !ok || lang.Name != "Python" {
+		t.Errorf("Expected language Python, got %q instead.\n", lang.Name)
+	}
+}
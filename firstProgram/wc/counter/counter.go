@@ -0,0 +1,191 @@
+// Package counter gathers the lines, words, characters (UTF-8 runes), bytes and
+// maximum line length of a text in a single streaming pass over the input, so any
+// tool in the repository can reuse the same counting logic as wc.
+package counter
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"unicode/utf8"
+)
+
+// Counts holds every metric gathered from a single read of the input
+type Counts struct {
+	Lines int
+	Words int
+	Runes int
+	Bytes int
+	// MaxLineLength is the length in runes of the longest line, not including the
+	// end-of-line marker
+	MaxLineLength int
+}
+
+// Add accumulates the metrics from o into c, used to calculate totals across inputs.
+// The maximum line length is the largest of both rather than their sum
+func (c *Counts) Add(o Counts) {
+	c.Lines += o.Lines
+	c.Words += o.Words
+	c.Runes += o.Runes
+	c.Bytes += o.Bytes
+
+	if o.MaxLineLength > c.MaxLineLength {
+		c.MaxLineLength = o.MaxLineLength
+	}
+}
+
+// newScanner returns a scanner reading r line by line. It lifts the default 64KB
+// limit of bufio.Scanner, so lines, or words when splitting them, of any length
+// are read
+func newScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, math.MaxInt)
+
+	return scanner
+}
+
+// Counter is an io.Writer gathering the Counts of everything written to it.
+// Unlike a bufio.Scanner it never holds a whole line in memory, so lines of any
+// length are counted, and it keeps its state between writes, so a word or a
+// multi-byte rune split across two writes is still counted once.
+// The zero value is ready to use, splitting words on white space.
+type Counter struct {
+	// Tokenizer defines what a word is. Whitespace is used if it's nil
+	Tokenizer Tokenizer
+
+	c Counts
+	// inWord is set while the last rune seen belongs to a word
+	inWord bool
+	// inLine is set when the current line has any content not yet counted as a line
+	inLine bool
+	// lastCR is set when the last rune seen is a carriage return
+	lastCR bool
+	// lineLen is the length in runes of the current line
+	lineLen int
+	// pending keeps the first bytes of a rune split between two writes
+	pending []byte
+}
+
+// Count reads r until EOF, gathering all the metrics at the same time.
+// A final line without a line ending is still counted as a line. If reading fails
+// the error is returned instead of a partial count
+func Count(r io.Reader) (Counts, error) {
+	return CountWith(r, Whitespace)
+}
+
+// CountWith works like Count but words are defined by the tokenizer tok
+func CountWith(r io.Reader, tok Tokenizer) (Counts, error) {
+	w := &Counter{Tokenizer: tok}
+
+	if _, err := io.Copy(w, r); err != nil {
+		return Counts{}, err
+	}
+
+	return w.Counts(), nil
+}
+
+// Write updates the counts with the contents of p. It never fails
+func (w *Counter) Write(p []byte) (int, error) {
+	buf := p
+
+	// Complete the rune left over from the previous write
+	if len(w.pending) > 0 {
+		buf = append(w.pending, p...)
+		w.pending = nil
+	}
+
+	for i := 0; i < len(buf); {
+		// Fast path for ASCII, the most common case
+		if buf[i] < utf8.RuneSelf {
+			w.rune(rune(buf[i]), 1)
+			i++
+			continue
+		}
+
+		// Wait for the next write if the rune is incomplete
+		if !utf8.FullRune(buf[i:]) {
+			w.pending = append([]byte{}, buf[i:]...)
+			break
+		}
+
+		// Invalid UTF-8 is decoded as utf8.RuneError of size 1, so every invalid
+		// byte is counted as one rune, the same as utf8.RuneCount does
+		r, size := utf8.DecodeRune(buf[i:])
+		w.rune(r, size)
+		i += size
+	}
+
+	return len(p), nil
+}
+
+// Counts returns the metrics for everything written so far. It can be called at
+// any time, for example to report progress, without affecting the Counter
+func (w Counter) Counts() Counts {
+	// Bytes still waiting to complete a rune will never do so at this point,
+	// count them as invalid runes. w is a copy, so this does not change the Counter
+	for range w.pending {
+		w.rune(utf8.RuneError, 1)
+	}
+
+	c := w.c
+
+	// The last line is counted even without a line ending
+	if w.inLine {
+		c.Lines++
+
+		if w.lineLen > c.MaxLineLength {
+			c.MaxLineLength = w.lineLen
+		}
+	}
+
+	return c
+}
+
+// rune updates the counts with a single decoded rune of size bytes
+func (w *Counter) rune(r rune, size int) {
+	w.c.Bytes += size
+	w.c.Runes++
+
+	tok := w.Tokenizer
+	if tok == nil {
+		tok = Whitespace
+	}
+
+	switch tok(r) {
+	case Separator:
+		w.inWord = false
+	case WordRune:
+		if !w.inWord {
+			w.c.Words++
+			w.inWord = true
+		}
+	case Joiner:
+		// Keeps the current word going, if any, but never starts one
+	case SingleRune:
+		w.c.Words++
+		w.inWord = false
+	}
+
+	if r == '\n' {
+		// The carriage return of a \r\n line ending is not part of the line
+		if w.lastCR {
+			w.lineLen--
+		}
+
+		w.c.Lines++
+
+		if w.lineLen > w.c.MaxLineLength {
+			w.c.MaxLineLength = w.lineLen
+		}
+
+		w.lineLen = 0
+		w.inLine = false
+		w.lastCR = false
+
+		return
+	}
+
+	w.lineLen++
+	w.inLine = true
+	w.lastCR = r == '\r'
+}
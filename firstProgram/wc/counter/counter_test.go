@@ -0,0 +1,142 @@
+package counter_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"pragprog.com/rggo/firstProgram/wc/counter"
+)
+
+// TestCountWords tests the Count function counts words
+func TestCountWords(t *testing.T) {
+	b := bytes.NewBufferString("word1 word2 word3 word4\n")
+
+	exp := 4
+
+	res, err := counter.Count(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Words != exp {
+		t.Errorf("Expected %d, got %d instead.\n", exp, res.Words)
+	}
+}
+
+// TestCountLines tests the Count function counts lines, including a final line
+// without a line ending
+func TestCountLines(t *testing.T) {
+	b := bytes.NewBufferString("word 1 word2 word3\nline2\nline3 word1")
+
+	exp := 3
+
+	res, err := counter.Count(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Lines != exp {
+		t.Errorf("Expected %d, got %d instead.\n", exp, res.Lines)
+	}
+}
+
+// TestCountAll tests the Count function gathers every metric in the same pass
+func TestCountAll(t *testing.T) {
+	b := bytes.NewBufferString("héllo wörld\r\nnaïve\n")
+
+	exp := counter.Counts{Lines: 2, Words: 3, Runes: 19, Bytes: 22, MaxLineLength: 11}
+
+	res, err := counter.Count(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res != exp {
+		t.Errorf("Expected %+v, got %+v instead.\n", exp, res)
+	}
+}
+
+// TestAdd tests the Add method sums the counts but keeps the longest line
+func TestAdd(t *testing.T) {
+	c := counter.Counts{Lines: 1, Words: 2, Runes: 3, Bytes: 4, MaxLineLength: 10}
+	c.Add(counter.Counts{Lines: 10, Words: 20, Runes: 30, Bytes: 40, MaxLineLength: 5})
+
+	exp := counter.Counts{Lines: 11, Words: 22, Runes: 33, Bytes: 44, MaxLineLength: 10}
+
+	if c != exp {
+		t.Errorf("Expected %+v, got %+v instead.\n", exp, c)
+	}
+}
+
+// TestCountLongLines tests the Count function with lines longer than the
+// bufio.Scanner default limit of 64KB
+func TestCountLongLines(t *testing.T) {
+	long := strings.Repeat("a", 1024*1024)
+	b := bytes.NewBufferString(long + " word\nshort\n" + long)
+
+	exp := counter.Counts{Lines: 3, Words: 4, Runes: 2*len(long) + 12, Bytes: 2*len(long) + 12,
+		MaxLineLength: len(long) + 5}
+
+	res, err := counter.Count(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res != exp {
+		t.Errorf("Expected %+v, got %+v instead.\n", exp, res)
+	}
+}
+
+// TestCountSplitWrites tests words and multi-byte runes are counted correctly when
+// the input arrives one byte at a time
+func TestCountSplitWrites(t *testing.T) {
+	r := iotest.OneByteReader(strings.NewReader("héllo wörld\r\nnaïve\n"))
+
+	exp := counter.Counts{Lines: 2, Words: 3, Runes: 19, Bytes: 22, MaxLineLength: 11}
+
+	res, err := counter.Count(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res != exp {
+		t.Errorf("Expected %+v, got %+v instead.\n", exp, res)
+	}
+}
+
+// TestCountInvalidUTF8 tests every invalid byte is counted as one rune, including
+// an incomplete rune at the end of the input
+func TestCountInvalidUTF8(t *testing.T) {
+	b := bytes.NewBufferString("a\xffb \xe2\x82")
+
+	exp := counter.Counts{Lines: 1, Words: 2, Runes: 6, Bytes: 6, MaxLineLength: 6}
+
+	res, err := counter.Count(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res != exp {
+		t.Errorf("Expected %+v, got %+v instead.\n", exp, res)
+	}
+}
+
+// TestCountReadError tests a read error is returned instead of a partial count
+func TestCountReadError(t *testing.T) {
+	errRead := errors.New("read failed")
+	r := io.MultiReader(strings.NewReader("word1 word2\n"), iotest.ErrReader(errRead))
+
+	res, err := counter.Count(r)
+
+	if !errors.Is(err, errRead) {
+		t.Errorf("Expected error %q, got %q instead.\n", errRead, err)
+	}
+
+	if res != (counter.Counts{}) {
+		t.Errorf("Expected no counts, got %+v instead.\n", res)
+	}
+}
@@ -0,0 +1,89 @@
+package counter
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// FreqOptions controls how words are compared when counting their frequency
+type FreqOptions struct {
+	// FoldCase makes words differing only in case count as the same word
+	FoldCase bool
+	// StripPunct removes leading and trailing punctuation from every word, so
+	// "word," and "(word)" count as "word". Words made only of punctuation are ignored
+	StripPunct bool
+	// Tokenizer defines what a word is. Whitespace is used if it's nil
+	Tokenizer Tokenizer
+}
+
+// WordCount is a word together with the number of times it appears
+type WordCount struct {
+	Word  string
+	Count int
+}
+
+// Frequencies reads r until EOF counting how many times each word appears.
+// Only the frequency map is kept in memory, not the input
+func Frequencies(r io.Reader, opts FreqOptions) (map[string]int, error) {
+	freq := map[string]int{}
+
+	scanner := newScanner(r)
+
+	// Words are split the same way as Count does, on Unicode white space by default
+	if opts.Tokenizer != nil {
+		scanner.Split(opts.Tokenizer.SplitFunc())
+	} else {
+		scanner.Split(bufio.ScanWords)
+	}
+
+	for scanner.Scan() {
+		word := scanner.Text()
+
+		if opts.StripPunct {
+			word = strings.TrimFunc(word, unicode.IsPunct)
+			if word == "" {
+				continue
+			}
+		}
+
+		if opts.FoldCase {
+			word = strings.ToLower(word)
+		}
+
+		freq[word]++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return freq, nil
+}
+
+// Top returns the n most frequent words in freq, ordered by count from the most
+// frequent, and alphabetically for words with the same count so the result is
+// always the same. If n is zero or negative all words are returned
+func Top(freq map[string]int, n int) []WordCount {
+	words := make([]WordCount, 0, len(freq))
+
+	for w, c := range freq {
+		words = append(words, WordCount{Word: w, Count: c})
+	}
+
+	sort.Slice(words, func(i, j int) bool {
+		if words[i].Count != words[j].Count {
+			return words[i].Count > words[j].Count
+		}
+
+		return words[i].Word < words[j].Word
+	})
+
+	if n > 0 && n < len(words) {
+		words = words[:n]
+	}
+
+	return words
+}
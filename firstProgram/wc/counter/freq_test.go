@@ -0,0 +1,77 @@
+package counter_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"pragprog.com/rggo/firstProgram/wc/counter"
+)
+
+// TestFrequencies tests the Frequencies function with the different options
+func TestFrequencies(t *testing.T) {
+	input := "The cat, the hat.\nTHE END -- the end\n"
+
+	testCases := []struct {
+		name string
+		opts counter.FreqOptions
+		exp  map[string]int
+	}{
+		{name: "NoOptions", opts: counter.FreqOptions{},
+			exp: map[string]int{"The": 1, "cat,": 1, "the": 2, "hat.": 1, "THE": 1, "END": 1, "--": 1, "end": 1},
+		},
+		{name: "FoldCase", opts: counter.FreqOptions{FoldCase: true},
+			exp: map[string]int{"the": 4, "cat,": 1, "hat.": 1, "end": 2, "--": 1},
+		},
+		{name: "StripPunct", opts: counter.FreqOptions{StripPunct: true},
+			exp: map[string]int{"The": 1, "cat": 1, "the": 2, "hat": 1, "THE": 1, "END": 1, "end": 1},
+		},
+		{name: "FoldCaseStripPunct", opts: counter.FreqOptions{FoldCase: true, StripPunct: true},
+			exp: map[string]int{"the": 4, "cat": 1, "hat": 1, "end": 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := counter.Frequencies(bytes.NewBufferString(input), tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tc.exp, res) {
+				t.Errorf("Expected %v, got %v instead.\n", tc.exp, res)
+			}
+		})
+	}
+}
+
+// TestTop tests the Top function orders by count and then alphabetically
+func TestTop(t *testing.T) {
+	freq := map[string]int{"b": 2, "a": 2, "c": 5, "d": 1}
+
+	testCases := []struct {
+		name string
+		n    int
+		exp  []counter.WordCount
+	}{
+		{name: "Top2", n: 2,
+			exp: []counter.WordCount{{Word: "c", Count: 5}, {Word: "a", Count: 2}},
+		},
+		{name: "All", n: 0,
+			exp: []counter.WordCount{{Word: "c", Count: 5}, {Word: "a", Count: 2}, {Word: "b", Count: 2}, {Word: "d", Count: 1}},
+		},
+		{name: "MoreThanWords", n: 10,
+			exp: []counter.WordCount{{Word: "c", Count: 5}, {Word: "a", Count: 2}, {Word: "b", Count: 2}, {Word: "d", Count: 1}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := counter.Top(freq, tc.n)
+
+			if !reflect.DeepEqual(tc.exp, res) {
+				t.Errorf("Expected %v, got %v instead.\n", tc.exp, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package counter
+
+import (
+	"io"
+	"math"
+	"sort"
+	"unicode/utf8"
+)
+
+// LineLengths holds how many lines of a text have each length, in bytes and in
+// runes, not including the end-of-line marker. Keeping a histogram instead of the
+// length of every line uses memory proportional to the number of different
+// lengths, which stays small however long the text is
+type LineLengths struct {
+	Lines int
+	// Bytes and Runes count the lines by their length
+	Bytes map[int]int
+	Runes map[int]int
+	// Longest is the line number, starting from 1, of the first of the longest
+	// lines in runes, or 0 if there are no lines
+	Longest int
+}
+
+// LengthStats summarizes the distribution of line lengths
+type LengthStats struct {
+	Min  int
+	Max  int
+	Mean float64
+	// P50, P90, P95 and P99 are the percentiles using the nearest-rank method, so
+	// they are always the length of an actual line
+	P50 int
+	P90 int
+	P95 int
+	P99 int
+}
+
+// MeasureLines reads r until EOF measuring the length of every line. If each is
+// not nil it's called with the number, starting from 1, and the lengths of every
+// line, for callers needing more than the distribution
+func MeasureLines(r io.Reader, each func(n, bytes, runes int)) (LineLengths, error) {
+	l := LineLengths{Bytes: map[int]int{}, Runes: map[int]int{}}
+	longest := 0
+
+	scanner := newScanner(r)
+
+	for scanner.Scan() {
+		// bufio.ScanLines drops the \n or \r\n line ending
+		line := scanner.Bytes()
+		runes := utf8.RuneCount(line)
+
+		l.Lines++
+		l.Bytes[len(line)]++
+		l.Runes[runes]++
+
+		if l.Longest == 0 || runes > longest {
+			l.Longest, longest = l.Lines, runes
+		}
+
+		if each != nil {
+			each(l.Lines, len(line), runes)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return LineLengths{}, err
+	}
+
+	return l, nil
+}
+
+// Merge adds the lines of o to l. The longest line is not merged, as the line
+// numbers of different texts can't be compared
+func (l *LineLengths) Merge(o LineLengths) {
+	if l.Bytes == nil {
+		l.Bytes = map[int]int{}
+	}
+	if l.Runes == nil {
+		l.Runes = map[int]int{}
+	}
+
+	l.Lines += o.Lines
+
+	for n, count := range o.Bytes {
+		l.Bytes[n] += count
+	}
+	for n, count := range o.Runes {
+		l.Runes[n] += count
+	}
+}
+
+// Summarize calculates the distribution of the lengths counted in hist, which
+// holds how many lines have each length. All values are zero if there are no lines
+func Summarize(hist map[int]int) LengthStats {
+	lengths := make([]int, 0, len(hist))
+	lines, sum := 0, 0
+
+	for n, count := range hist {
+		if count <= 0 {
+			continue
+		}
+
+		lengths = append(lengths, n)
+		lines += count
+		sum += n * count
+	}
+
+	if lines == 0 {
+		return LengthStats{}
+	}
+
+	sort.Ints(lengths)
+
+	return LengthStats{
+		Min:  lengths[0],
+		Max:  lengths[len(lengths)-1],
+		Mean: float64(sum) / float64(lines),
+		P50:  percentile(lengths, hist, lines, 50),
+		P90:  percentile(lengths, hist, lines, 90),
+		P95:  percentile(lengths, hist, lines, 95),
+		P99:  percentile(lengths, hist, lines, 99),
+	}
+}
+
+// percentile returns the p percentile of the lines counted in hist using the
+// nearest-rank method. lengths holds the lengths in hist in increasing order
+func percentile(lengths []int, hist map[int]int, lines int, p float64) int {
+	rank := int(math.Ceil(p / 100 * float64(lines)))
+	if rank < 1 {
+		rank = 1
+	}
+
+	// The lines up to the rank are the shortest ones, so add up the lines of each
+	// length from the shortest until reaching it
+	seen := 0
+
+	for _, n := range lengths {
+		seen += hist[n]
+		if seen >= rank {
+			return n
+		}
+	}
+
+	return lengths[len(lengths)-1]
+}
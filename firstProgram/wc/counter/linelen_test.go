@@ -0,0 +1,98 @@
+package counter_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"pragprog.com/rggo/firstProgram/wc/counter"
+)
+
+// TestMeasureLines tests the line lengths in bytes and runes, and the longest line
+func TestMeasureLines(t *testing.T) {
+	b := bytes.NewBufferString("short\r\nnaïve line\n\nlongest line here\nlongest line also")
+
+	exp := counter.LineLengths{
+		Lines:   5,
+		Bytes:   map[int]int{5: 1, 11: 1, 0: 1, 17: 2},
+		Runes:   map[int]int{5: 1, 10: 1, 0: 1, 17: 2},
+		Longest: 4,
+	}
+
+	lines := [][3]int{}
+
+	res, err := counter.MeasureLines(b, func(n, bytes, runes int) {
+		lines = append(lines, [3]int{n, bytes, runes})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(exp, res) {
+		t.Errorf("Expected %+v, got %+v instead.\n", exp, res)
+	}
+
+	expLines := [][3]int{{1, 5, 5}, {2, 11, 10}, {3, 0, 0}, {4, 17, 17}, {5, 17, 17}}
+	if !reflect.DeepEqual(expLines, lines) {
+		t.Errorf("Expected lines %v, got %v instead.\n", expLines, lines)
+	}
+}
+
+// TestMerge tests adding up the lines of several texts
+func TestMerge(t *testing.T) {
+	total := counter.LineLengths{}
+
+	for _, text := range []string{"ab\nabc\n", "abc\n\n"} {
+		l, err := counter.MeasureLines(bytes.NewBufferString(text), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		total.Merge(l)
+	}
+
+	exp := counter.LineLengths{
+		Lines: 4,
+		Bytes: map[int]int{2: 1, 3: 2, 0: 1},
+		Runes: map[int]int{2: 1, 3: 2, 0: 1},
+	}
+
+	if !reflect.DeepEqual(exp, total) {
+		t.Errorf("Expected %+v, got %+v instead.\n", exp, total)
+	}
+}
+
+// TestSummarize tests the distribution of lengths
+func TestSummarize(t *testing.T) {
+	hundred := map[int]int{}
+	for i := 100; i >= 1; i-- {
+		hundred[i]++
+	}
+
+	testCases := []struct {
+		name string
+		hist map[int]int
+		exp  counter.LengthStats
+	}{
+		{name: "Empty", hist: map[int]int{}, exp: counter.LengthStats{}},
+		{name: "One", hist: map[int]int{7: 1},
+			exp: counter.LengthStats{Min: 7, Max: 7, Mean: 7, P50: 7, P90: 7, P95: 7, P99: 7},
+		},
+		{name: "Hundred", hist: hundred,
+			exp: counter.LengthStats{Min: 1, Max: 100, Mean: 50.5, P50: 50, P90: 90, P95: 95, P99: 99},
+		},
+		{name: "Repeated", hist: map[int]int{0: 0, 2: 5, 10: 4, 80: 1},
+			exp: counter.LengthStats{Min: 2, Max: 80, Mean: 13, P50: 2, P90: 10, P95: 80, P99: 80},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := counter.Summarize(tc.hist)
+
+			if res != tc.exp {
+				t.Errorf("Expected %+v, got %+v instead.\n", tc.exp, res)
+			}
+		})
+	}
+}
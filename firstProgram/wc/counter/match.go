@@ -0,0 +1,37 @@
+package counter
+
+import (
+	"io"
+	"regexp"
+)
+
+// Matches reads r until EOF counting the matches of each of the patterns,
+// returning one count per pattern in the same order. When lines is set it counts
+// the lines matching each pattern instead of every match, like grep -c.
+// Matches never span more than one line
+func Matches(r io.Reader, patterns []*regexp.Regexp, lines bool) ([]int, error) {
+	matches := make([]int, len(patterns))
+
+	scanner := newScanner(r)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		for i, re := range patterns {
+			if lines {
+				if re.Match(line) {
+					matches[i]++
+				}
+				continue
+			}
+
+			matches[i] += len(re.FindAllIndex(line, -1))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,44 @@
+package counter_test
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"pragprog.com/rggo/firstProgram/wc/counter"
+)
+
+// TestMatches tests the Matches function counting matches and matching lines
+func TestMatches(t *testing.T) {
+	input := "ERROR disk full\nINFO ok\nERROR ERROR retry\n" + strings.Repeat("x", 100000) + " WARN\n"
+
+	patterns := []*regexp.Regexp{
+		regexp.MustCompile(`ERROR`),
+		regexp.MustCompile(`WARN|INFO`),
+		regexp.MustCompile(`^DEBUG`),
+	}
+
+	testCases := []struct {
+		name  string
+		lines bool
+		exp   []int
+	}{
+		{name: "Matches", lines: false, exp: []int{3, 2, 0}},
+		{name: "MatchingLines", lines: true, exp: []int{2, 2, 0}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := counter.Matches(bytes.NewBufferString(input), patterns, tc.lines)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tc.exp, res) {
+				t.Errorf("Expected %v, got %v instead.\n", tc.exp, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package counter
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxInvalidOffsets is how many offsets of invalid UTF-8 sequences Inspect keeps
+const MaxInvalidOffsets = 5
+
+// Stats holds statistics about the characters and line endings of a text, useful
+// to find out its encoding and problems such as invalid UTF-8 or mixed line endings
+type Stats struct {
+	// ASCII and NonASCII count the valid runes of each kind
+	ASCII    int
+	NonASCII int
+	// Invalid counts the sequences of one or more consecutive bytes that are not
+	// valid UTF-8
+	Invalid int
+	// InvalidOffsets holds the byte offsets of the first MaxInvalidOffsets
+	// invalid sequences
+	InvalidOffsets []int64
+	// Control counts the control characters other than tab, line feed and
+	// carriage return
+	Control int
+	// CRLF, LF and CR count the line endings of each kind. A carriage return is
+	// counted as CR only when it's not followed by a line feed
+	CRLF int
+	LF   int
+	CR   int
+	// TrailingNewline is set when the text ends with a line feed
+	TrailingNewline bool
+}
+
+// Inspect reads r until EOF gathering the Stats of its contents
+func Inspect(r io.Reader) (Stats, error) {
+	s := Stats{InvalidOffsets: []int64{}}
+
+	br := bufio.NewReader(r)
+
+	var offset int64
+	var prev rune
+	prevInvalid := false
+
+	for {
+		ch, size, err := br.ReadRune()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Stats{}, err
+		}
+
+		// ReadRune returns utf8.RuneError of size 1 for every invalid byte, while a
+		// valid encoding of utf8.RuneError has size 3
+		invalid := ch == utf8.RuneError && size == 1
+
+		switch {
+		case invalid:
+			// Consecutive invalid bytes make up a single sequence
+			if !prevInvalid {
+				s.Invalid++
+
+				if len(s.InvalidOffsets) < MaxInvalidOffsets {
+					s.InvalidOffsets = append(s.InvalidOffsets, offset)
+				}
+			}
+		case ch < utf8.RuneSelf:
+			s.ASCII++
+		default:
+			s.NonASCII++
+		}
+
+		if prev == '\r' && ch != '\n' {
+			s.CR++
+		}
+
+		switch {
+		case ch == '\n' && prev == '\r':
+			s.CRLF++
+		case ch == '\n':
+			s.LF++
+		case ch == '\r', ch == '\t':
+		case !invalid && unicode.IsControl(ch):
+			s.Control++
+		}
+
+		offset += int64(size)
+		prev = ch
+		prevInvalid = invalid
+	}
+
+	if prev == '\r' {
+		s.CR++
+	}
+
+	s.TrailingNewline = prev == '\n'
+
+	return s, nil
+}
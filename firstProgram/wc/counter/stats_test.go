@@ -0,0 +1,48 @@
+package counter_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"pragprog.com/rggo/firstProgram/wc/counter"
+)
+
+// TestInspect tests the Inspect function gathers the character statistics
+func TestInspect(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		exp   counter.Stats
+	}{
+		{name: "Empty", input: "",
+			exp: counter.Stats{InvalidOffsets: []int64{}},
+		},
+		{name: "ASCIILF", input: "one\ntwo\n",
+			exp: counter.Stats{ASCII: 8, LF: 2, InvalidOffsets: []int64{}, TrailingNewline: true},
+		},
+		{name: "MixedEndings", input: "héllo\r\nwörld\rend\n\tlast",
+			exp: counter.Stats{ASCII: 20, NonASCII: 2, CRLF: 1, LF: 1, CR: 1, InvalidOffsets: []int64{}},
+		},
+		{name: "Invalid", input: "a\xff\xfeb\xe2\x82c\x00\x1b�\n",
+			exp: counter.Stats{ASCII: 6, NonASCII: 1, Invalid: 2, InvalidOffsets: []int64{1, 4}, Control: 2,
+				LF: 1, TrailingNewline: true},
+		},
+		{name: "ManyInvalid", input: "\xffa\xffa\xffa\xffa\xffa\xffa",
+			exp: counter.Stats{ASCII: 6, Invalid: 6, InvalidOffsets: []int64{0, 2, 4, 6, 8}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := counter.Inspect(bytes.NewBufferString(tc.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tc.exp, res) {
+				t.Errorf("Expected %+v, got %+v instead.\n", tc.exp, res)
+			}
+		})
+	}
+}
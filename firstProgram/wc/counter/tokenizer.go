@@ -0,0 +1,128 @@
+package counter
+
+import (
+	"bufio"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RuneClass tells how a rune takes part in words
+type RuneClass int
+
+const (
+	// Separator runes are never part of a word, they split words
+	Separator RuneClass = iota
+	// WordRune runes make up words
+	WordRune
+	// Joiner runes are part of a word only when they follow a word rune, such as the
+	// apostrophe in "don't", but never start a word
+	Joiner
+	// SingleRune runes are a word by themselves, such as CJK ideographs written
+	// without spaces between words
+	SingleRune
+)
+
+// Tokenizer defines what a word is by classifying every rune
+type Tokenizer func(r rune) RuneClass
+
+// Whitespace splits words on Unicode white space only, the same as bufio.ScanWords.
+// This is the default tokenizer
+func Whitespace(r rune) RuneClass {
+	if unicode.IsSpace(r) {
+		return Separator
+	}
+
+	return WordRune
+}
+
+// Unicode splits words the way most languages expect, close to the Unicode word
+// boundaries: words are made of letters, digits and marks, so punctuation and
+// hyphens split words but apostrophes and periods inside a word don't, as in
+// "don't" or "3.14". Ideographic scripts like Chinese and Japanese don't use spaces,
+// so each ideograph or kana is counted as a word
+func Unicode(r rune) RuneClass {
+	switch {
+	case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+		return SingleRune
+	case unicode.IsLetter(r), unicode.IsDigit(r), unicode.IsMark(r), r == '_':
+		return WordRune
+	case r == '\'', r == '’', r == '.':
+		return Joiner
+	default:
+		return Separator
+	}
+}
+
+// Delimiters returns a Tokenizer splitting words on Unicode white space and on any
+// of the runes in delims
+func Delimiters(delims string) Tokenizer {
+	return func(r rune) RuneClass {
+		if unicode.IsSpace(r) || strings.ContainsRune(delims, r) {
+			return Separator
+		}
+
+		return WordRune
+	}
+}
+
+// SplitFunc returns a split function for a bufio.Scanner returning each word as
+// defined by the tokenizer, the same words counted by a Counter using it.
+// Joiner runes at the end of a word are not part of the word
+func (t Tokenizer) SplitFunc() bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		start := 0
+
+		// Skip anything before the start of the next word
+		for start < len(data) {
+			if !utf8.FullRune(data[start:]) && !atEOF {
+				return start, nil, nil
+			}
+
+			r, width := utf8.DecodeRune(data[start:])
+			class := t(r)
+
+			if class == SingleRune {
+				return start + width, data[start : start+width], nil
+			}
+
+			if class == WordRune {
+				break
+			}
+
+			start += width
+		}
+
+		// end is the end of the word without any trailing joiners
+		end := start
+
+		for i := start; i < len(data); {
+			if !utf8.FullRune(data[i:]) && !atEOF {
+				break
+			}
+
+			r, width := utf8.DecodeRune(data[i:])
+
+			switch t(r) {
+			case WordRune:
+				i += width
+				end = i
+				continue
+			case Joiner:
+				i += width
+				continue
+			}
+
+			// A separator or single rune ends the word
+			return end, data[start:end], nil
+		}
+
+		// If we're at EOF, we have a final, non-empty word. Return it
+		if atEOF && end > start {
+			return len(data), data[start:end], nil
+		}
+
+		// Request more data
+		return start, nil, nil
+	}
+}
@@ -0,0 +1,80 @@
+package counter_test
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"pragprog.com/rggo/firstProgram/wc/counter"
+)
+
+// TestTokenizers tests the words counted and split by every tokenizer agree
+func TestTokenizers(t *testing.T) {
+	input := "Don't stop: well-known 3.14 東京タワー, 'quoted' a,b;c\n"
+
+	testCases := []struct {
+		name string
+		tok  counter.Tokenizer
+		exp  []string
+	}{
+		{name: "Whitespace", tok: counter.Whitespace,
+			exp: []string{"Don't", "stop:", "well-known", "3.14", "東京タワー,", "'quoted'", "a,b;c"},
+		},
+		{name: "Unicode", tok: counter.Unicode,
+			exp: []string{"Don't", "stop", "well", "known", "3.14", "東", "京", "タ", "ワ", "ー", "quoted", "a", "b", "c"},
+		},
+		{name: "Delimiters", tok: counter.Delimiters(",;:-"),
+			exp: []string{"Don't", "stop", "well", "known", "3.14", "東京タワー", "'quoted'", "a", "b", "c"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Feed the input one byte at a time so words and runes are split
+			// between reads
+			res, err := counter.CountWith(iotest.OneByteReader(strings.NewReader(input)), tc.tok)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.Words != len(tc.exp) {
+				t.Errorf("Expected %d words, got %d instead.\n", len(tc.exp), res.Words)
+			}
+
+			scanner := bufio.NewScanner(iotest.OneByteReader(strings.NewReader(input)))
+			scanner.Split(tc.tok.SplitFunc())
+
+			words := []string{}
+			for scanner.Scan() {
+				words = append(words, scanner.Text())
+			}
+
+			if err := scanner.Err(); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tc.exp, words) {
+				t.Errorf("Expected %q, got %q instead.\n", tc.exp, words)
+			}
+		})
+	}
+}
+
+// TestFrequenciesTokenizer tests Frequencies splits words with the given tokenizer
+func TestFrequenciesTokenizer(t *testing.T) {
+	b := bytes.NewBufferString("well-known, well-made")
+
+	exp := map[string]int{"well": 2, "known": 1, "made": 1}
+
+	res, err := counter.Frequencies(b, counter.FreqOptions{Tokenizer: counter.Unicode})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(exp, res) {
+		t.Errorf("Expected %v, got %v instead.\n", exp, res)
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import "errors"
+
+var (
+	ErrFilesFailed      = errors.New("files could not be counted")
+	ErrInvalidWorkers   = errors.New("invalid number of workers")
+	ErrInvalidPattern   = errors.New("invalid pattern")
+	ErrConflictingModes = errors.New("conflicting modes")
+	ErrInvalidFormat    = errors.New("invalid output format")
+	ErrInvalidFiles     = errors.New("invalid files")
+	ErrInvalidInterval  = errors.New("invalid interval")
+	ErrInvalidGlob      = errors.New("invalid glob")
+	ErrInvalidLanguage  = errors.New("invalid language")
+	ErrInvalidTokenizer = errors.New("invalid tokenizer")
+)
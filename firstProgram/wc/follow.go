@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"pragprog.com/rggo/firstProgram/wc/counter"
+)
+
+// runFollow keeps counting the named file as it grows, like tail -f, printing the
+// lines and words counted so far every interval together with the rate per second
+// since the previous report. Words are defined by tok. If the file is truncated or
+// replaced by a new file, as log rotation does, the counts start again from the
+// beginning of the file.
+// It runs until ctx is cancelled, printing a last report before returning
+func runFollow(ctx context.Context, fname string, interval time.Duration, tok counter.Tokenizer, out, errOut io.Writer) error {
+	if interval <= 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidInterval, interval)
+	}
+
+	f, err := os.Open(fname)
+	if err != nil {
+		return fmt.Errorf("cannot open file: %w", err)
+	}
+
+	// f changes when the file is rotated, so close whichever is open at the end
+	defer func() {
+		f.Close()
+	}()
+
+	// The Counter keeps its state between writes, so the file can be counted as
+	// it grows without reading it again
+	w := &counter.Counter{Tokenizer: tok}
+
+	// offset is the number of bytes read from f, used to detect truncation
+	var offset int64
+
+	prev := counter.Counts{}
+	last := time.Now()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Count everything appended since the last read
+		n, err := io.Copy(w, f)
+		if err != nil {
+			return fmt.Errorf("cannot read file %s: %w", fname, err)
+		}
+		offset += n
+
+		select {
+		case <-ctx.Done():
+			return printFollow(out, w.Counts(), prev, time.Since(last))
+		case now := <-ticker.C:
+			if err := printFollow(out, w.Counts(), prev, now.Sub(last)); err != nil {
+				return err
+			}
+
+			prev = w.Counts()
+			last = now
+		}
+
+		// Check if the file was rotated or truncated. If the name is temporarily
+		// missing while rotating, keep reading the open file
+		fi, err := os.Stat(fname)
+		if err != nil {
+			continue
+		}
+
+		cur, err := f.Stat()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case !os.SameFile(fi, cur):
+			fmt.Fprintf(errOut, "%s: file replaced, counting from the start\n", fname)
+
+			nf, err := os.Open(fname)
+			if err != nil {
+				return fmt.Errorf("cannot open file: %w", err)
+			}
+
+			f.Close()
+			f = nf
+		case fi.Size() < offset:
+			fmt.Fprintf(errOut, "%s: file truncated, counting from the start\n", fname)
+
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+
+		// Start counting again from the beginning of the file
+		*w = counter.Counter{Tokenizer: tok}
+		offset = 0
+		prev = counter.Counts{}
+	}
+}
+
+// printFollow prints the current counts and the rates per second since prev was
+// reported elapsed time ago
+func printFollow(out io.Writer, c, prev counter.Counts, elapsed time.Duration) error {
+	secs := elapsed.Seconds()
+	if secs <= 0 {
+		secs = 1
+	}
+
+	linesRate := float64(c.Lines-prev.Lines) / secs
+	wordsRate := float64(c.Words-prev.Words) / secs
+
+	_, err := fmt.Fprintf(out, "%d lines %d words %.1f lines/s %.1f words/s\n",
+		c.Lines, c.Words, linesRate, wordsRate)
+	return err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pragprog.com/rggo/firstProgram/wc/counter"
+)
+
+// syncBuffer is a bytes.Buffer safe to use from several goroutines, so the test can
+// read the output while runFollow is still writing to it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// TestRunFollow tests runFollow keeps counting as the file grows and starts again
+// when the file is truncated or replaced
+func TestRunFollow(t *testing.T) {
+	tempDir := t.TempDir()
+	fname := filepath.Join(tempDir, "app.log")
+
+	if err := os.WriteFile(fname, []byte("line one\nline two\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var out, errOut syncBuffer
+	done := make(chan error)
+
+	go func() {
+		done <- runFollow(ctx, fname, 10*time.Millisecond, counter.Whitespace, &out, &errOut)
+	}()
+
+	// waitFor waits until the last report printed by runFollow starts with exp
+	waitFor := func(exp string) {
+		t.Helper()
+
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if strings.HasPrefix(lines[len(lines)-1], exp) {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		t.Fatalf("Expected report %q, got %q instead.\n", exp, out.String())
+	}
+
+	waitFor("2 lines 4 words")
+
+	// Append to the file
+	f, err := os.OpenFile(fname, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("line three\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	waitFor("3 lines 6 words")
+
+	// Truncate the file
+	if err := os.WriteFile(fname, []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor("1 lines 1 words")
+
+	// Replace the file, as log rotation does
+	rotated := filepath.Join(tempDir, "new.log")
+	if err := os.WriteFile(rotated, []byte("a b\nc d\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(rotated, fname); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor("2 lines 4 words")
+
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, exp := range []string{"file truncated", "file replaced"} {
+		if !strings.Contains(errOut.String(), exp) {
+			t.Errorf("Expected %q in error output, got %q instead.\n", exp, errOut.String())
+		}
+	}
+}
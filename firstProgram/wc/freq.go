@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io"
+
+	"pragprog.com/rggo/firstProgram/wc/counter"
+)
+
+// runFreq reports the cfg.top most frequent words across all the files, with one
+// result per word and its count
+func runFreq(filenames []string, cfg config, tok counter.Tokenizer, in io.Reader, out, errOut io.Writer) error {
+	results := make([]map[string]int, len(filenames))
+
+	opts := counter.FreqOptions{
+		FoldCase:   cfg.fold,
+		StripPunct: cfg.strip,
+		Tokenizer:  tok,
+	}
+
+	errs := process(filenames, cfg, in, func(i int, r io.Reader) error {
+		freq, err := counter.Frequencies(r, opts)
+		results[i] = freq
+		return err
+	})
+
+	// Merge the frequencies of every file into a single map
+	total := map[string]int{}
+
+	eachCounted(errs, errOut, func(i int) {
+		for w, c := range results[i] {
+			total[w] += c
+		}
+	})
+
+	// Each word is a row named after the word, with its count as the only value
+	rep := report{columns: []string{"count"}}
+
+	for _, wc := range counter.Top(total, cfg.top) {
+		rep.rows = append(rep.rows, row{name: wc.Word, values: []int{wc.Count}})
+	}
+
+	return printResults(out, rep, cfg.format, errs)
+}
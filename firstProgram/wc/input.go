@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Magic numbers identifying compressed data by its first bytes. The whole signature
+// is checked, so text that happens to start with the same letters, such as "BZh",
+// is not mistaken for compressed data
+var (
+	// gzip streams start with their ID bytes and the deflate compression method
+	gzipMagic = []byte{0x1f, 0x8b, 0x08}
+	// bzip2 streams start with "BZh" and the block size, '1' to '9', followed by the
+	// magic number of the first block, or of the end of the stream when it's empty
+	bzip2Magic      = []byte("BZh")
+	bzip2BlockMagic = []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}
+	bzip2EndMagic   = []byte{0x17, 0x72, 0x45, 0x38, 0x50, 0x90}
+)
+
+// decompress detects compressed data in r by its magic bytes and returns a reader
+// for the decompressed content. Data that is not compressed is returned unchanged.
+// Only the start of the input is inspected, so it works for the Standard Input too
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+
+	// Peek returns the next bytes without advancing the reader, so nothing is lost
+	// if the data turns out not to be compressed. An error here only means the input
+	// is shorter than the longest magic number
+	magic, _ := br.Peek(len(bzip2Magic) + 1 + len(bzip2BlockMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	case isBzip2(magic):
+		return bzip2.NewReader(br), nil
+	default:
+		return br, nil
+	}
+}
+
+// isBzip2 checks if magic holds the complete signature of a bzip2 stream
+func isBzip2(magic []byte) bool {
+	n := len(bzip2Magic)
+
+	if len(magic) < n+1+len(bzip2BlockMagic) || !bytes.HasPrefix(magic, bzip2Magic) {
+		return false
+	}
+
+	if level := magic[n]; level < '1' || level > '9' {
+		return false
+	}
+
+	block := magic[n+1:]
+
+	return bytes.Equal(block, bzip2BlockMagic) || bytes.Equal(block, bzip2EndMagic)
+}
+
+// readFileList reads the list of file names to count from the named file, or from
+// in if the name is "-". The names are separated by NUL characters, as printed by
+// find -print0, which allows any character in the names. If the list has no NUL
+// characters the names are separated by newlines instead. Empty names are ignored
+func readFileList(fname string, in io.Reader) ([]string, error) {
+	r := in
+
+	if fname != "-" {
+		f, err := os.Open(fname)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open file list: %w", err)
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file list: %w", err)
+	}
+
+	sep := []byte{0}
+	if !bytes.Contains(data, sep) {
+		sep = []byte{'\n'}
+	}
+
+	names := []string{}
+
+	for _, name := range bytes.Split(data, sep) {
+		// Allow Windows line endings in newline separated lists
+		if sep[0] == '\n' {
+			name = bytes.TrimSuffix(name, []byte{'\r'})
+		}
+
+		if len(name) == 0 {
+			continue
+		}
+
+		// The Standard Input was already used to read the list
+		if fname == "-" && string(name) == "-" {
+			return nil, fmt.Errorf("%w: cannot read - from a file list read from the Standard Input", ErrInvalidFiles)
+		}
+
+		names = append(names, string(name))
+	}
+
+	return names, nil
+}
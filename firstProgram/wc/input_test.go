@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestDecompress tests compressed data is detected and decompressed while any
+// other data is returned unchanged
+func TestDecompress(t *testing.T) {
+	exp, err := os.ReadFile("./testdata/file2.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name string
+		file string
+	}{
+		{name: "Plain", file: "./testdata/file2.txt"},
+		{name: "Gzip", file: "./testdata/file2.txt.gz"},
+		{name: "Bzip2", file: "./testdata/file2.txt.bz2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := os.Open(tc.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			r, err := decompress(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(exp, res) {
+				t.Errorf("Expected %q, got %q instead.\n", exp, res)
+			}
+		})
+	}
+
+	// Inputs shorter than the magic numbers, or starting with only part of them,
+	// are not compressed
+	plainCases := []struct {
+		name  string
+		input string
+	}{
+		{name: "Short", input: "B"},
+		{name: "Bzip2Prefix", input: "BZh is a plain text line\n"},
+		{name: "Bzip2Level", input: "BZh9 is a plain text line\n"},
+		{name: "GzipPrefix", input: "\x1f\x8b is not gzip\n"},
+	}
+
+	for _, tc := range plainCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := decompress(bytes.NewBufferString(tc.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(res) != tc.input {
+				t.Errorf("Expected %q, got %q instead.\n", tc.input, res)
+			}
+		})
+	}
+}
+
+// TestReadFileList tests reading NUL and newline separated lists of file names
+func TestReadFileList(t *testing.T) {
+	testCases := []struct {
+		name   string
+		fname  string
+		input  string
+		exp    []string
+		expErr error
+	}{
+		{name: "NULFile", fname: "./testdata/files0.list",
+			exp: []string{"./testdata/file1.txt", "./testdata/file2.txt"},
+		},
+		{name: "NULStdin", fname: "-", input: "a file\nwith newline\x00b.txt\x00\x00",
+			exp: []string{"a file\nwith newline", "b.txt"},
+		},
+		{name: "NewlineStdin", fname: "-", input: "a.txt\r\nb c.txt\n\n",
+			exp: []string{"a.txt", "b c.txt"},
+		},
+		{name: "Empty", fname: "-", input: "",
+			exp: []string{},
+		},
+		{name: "StdinTwice", fname: "-", input: "a.txt\n-\n",
+			expErr: ErrInvalidFiles,
+		},
+		{name: "MissingFile", fname: "./testdata/fakefile.list",
+			expErr: os.ErrNotExist,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := readFileList(tc.fname, bytes.NewBufferString(tc.input))
+
+			if tc.expErr != nil {
+				if !errors.Is(err, tc.expErr) {
+					t.Errorf("Expected error %q, got %q instead.\n", tc.expErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(tc.exp, res) {
+				t.Errorf("Expected %q, got %q instead.\n", tc.exp, res)
+			}
+		})
+	}
+}
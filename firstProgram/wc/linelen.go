@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"pragprog.com/rggo/firstProgram/wc/counter"
+)
+
+// lineLenColumns holds the names of the values reported in line length mode
+var lineLenColumns = []string{
+	"lines",
+	"min_bytes", "max_bytes", "mean_bytes", "p50_bytes", "p90_bytes", "p95_bytes", "p99_bytes",
+	"min_runes", "max_runes", "mean_runes", "p50_runes", "p90_runes", "p95_runes", "p99_runes",
+	"longest_line",
+}
+
+// runLineLen reports the distribution of line lengths of every file, with one
+// result per file followed by a total for all the lines together. The table shows
+// the mean rounded to the nearest integer, while csv and json show two decimals.
+// If cfg.over is set it reports every line longer than cfg.over runes instead,
+// named after the file and line number.
+// When reading only the Standard Input there is no total
+func runLineLen(filenames []string, stdin bool, cfg config, in io.Reader, out, errOut io.Writer) error {
+	results := make([]counter.LineLengths, len(filenames))
+	// long holds the rows of the lines longer than cfg.over in each file
+	long := make([][]row, len(filenames))
+
+	errs := process(filenames, cfg, in, func(i int, r io.Reader) error {
+		var each func(n, bytes, runes int)
+
+		if cfg.over > 0 {
+			each = func(n, bytes, runes int) {
+				if runes <= cfg.over {
+					return
+				}
+
+				name := fmt.Sprintf("%s:%d", filenames[i], n)
+				if stdin {
+					name = fmt.Sprintf("%d", n)
+				}
+
+				long[i] = append(long[i], row{name: name, values: []int{bytes, runes}})
+			}
+		}
+
+		l, err := counter.MeasureLines(r, each)
+		results[i] = l
+		return err
+	})
+
+	if stdin && errs[0] != nil {
+		return errs[0]
+	}
+
+	if cfg.over > 0 {
+		rep := report{columns: []string{"bytes", "runes"}}
+
+		eachCounted(errs, errOut, func(i int) {
+			rep.rows = append(rep.rows, long[i]...)
+		})
+
+		return printResults(out, rep, cfg.format, errs)
+	}
+
+	total := counter.LineLengths{}
+
+	return reportFiles(out, errOut, report{columns: lineLenColumns}, cfg.format, filenames, stdin, errs,
+		func(i int, name string) row {
+			total.Merge(results[i])
+			return lineLenRow(name, results[i], results[i].Longest)
+		},
+		func() row {
+			// The line number of the longest line is meaningless across files, so the
+			// total reports it as 0. Use the per file results to find it
+			return lineLenRow("total", total, 0)
+		})
+}
+
+// meanBytes and meanRunes are the indexes of the means in lineLenColumns
+const (
+	meanBytes = 3
+	meanRunes = 10
+)
+
+// lineLenRow returns the report row for the line lengths, in the same order as
+// lineLenColumns
+func lineLenRow(name string, l counter.LineLengths, longest int) row {
+	b := counter.Summarize(l.Bytes)
+	r := counter.Summarize(l.Runes)
+
+	return row{
+		name: name,
+		values: []int{
+			l.Lines,
+			b.Min, b.Max, int(math.Round(b.Mean)), b.P50, b.P90, b.P95, b.P99,
+			r.Min, r.Max, int(math.Round(r.Mean)), r.P50, r.P90, r.P95, r.P99,
+			longest,
+		},
+		floats: map[int]float64{
+			meanBytes: math.Round(b.Mean*100) / 100,
+			meanRunes: math.Round(r.Mean*100) / 100,
+		},
+	}
+}
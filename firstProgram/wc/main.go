@@ -1,43 +1,380 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"time"
+
+	"pragprog.com/rggo/firstProgram/wc/counter"
 )
 
+// we use a struct to hold the selected counting modes rather than pass a large
+// number of boolean function parameters
+type config struct {
+	// count lines
+	lines bool
+	// count words
+	words bool
+	// count UTF-8 runes (characters)
+	runes bool
+	// count bytes
+	bytes bool
+	// report the length of the longest line
+	maxLine bool
+	// number of files counted concurrently
+	workers int
+	// report the most frequent words instead of the counts
+	freq bool
+	// number of words reported in frequency mode
+	top int
+	// ignore case when comparing words in frequency mode
+	fold bool
+	// strip leading and trailing punctuation from words in frequency mode
+	strip bool
+	// count the matches of these regular expressions instead
+	patterns []string
+	// count the lines matching each pattern rather than every match
+	matchLines bool
+	// output format: table, csv or json
+	format string
+	// keep counting a growing file
+	follow bool
+	// how often to report the counts in follow mode
+	interval time.Duration
+	// count the files below directories
+	recursive bool
+	// globs selecting the files to count below directories
+	include []string
+	// globs selecting the files and directories to skip below directories
+	exclude []string
+	// classify lines as code, comment or blank
+	code bool
+	// language of the input in code mode, detected from the file name if empty
+	lang string
+	// how words are split: whitespace, unicode or delims
+	tokenizer string
+	// extra word delimiters for the delims tokenizer
+	delims string
+	// report character statistics instead of the counts
+	stats bool
+	// report the distribution of line lengths instead of the counts
+	lineLen bool
+	// report the lines longer than this many runes in line length mode
+	over int
+	// read the names of the files to count from this file
+	filesFrom string
+	// errors found walking the directories, by path, reported when counting the path
+	walkErrs map[string]error
+}
+
+// values returns the metrics selected in cfg, always in the same column order
+// as the coreutils wc: lines, words, characters, bytes and maximum line length
+func values(c counter.Counts, cfg config) []int {
+	v := []int{}
+
+	if cfg.lines {
+		v = append(v, c.Lines)
+	}
+	if cfg.words {
+		v = append(v, c.Words)
+	}
+	if cfg.runes {
+		v = append(v, c.Runes)
+	}
+	if cfg.bytes {
+		v = append(v, c.Bytes)
+	}
+	if cfg.maxLine {
+		v = append(v, c.MaxLineLength)
+	}
+
+	return v
+}
+
+// columns returns the names of the metrics selected in cfg, in the same order
+// as values
+func columns(cfg config) []string {
+	c := []string{}
+
+	if cfg.lines {
+		c = append(c, "lines")
+	}
+	if cfg.words {
+		c = append(c, "words")
+	}
+	if cfg.runes {
+		c = append(c, "chars")
+	}
+	if cfg.bytes {
+		c = append(c, "bytes")
+	}
+	if cfg.maxLine {
+		c = append(c, "max_line_length")
+	}
+
+	return c
+}
+
 func main() {
-	// Defining a boolean flag -l to count lines instead of words
+	// Defining boolean flags to select what to count. They can be combined and the
+	// input is still read only once
 	lines := flag.Bool("l", false, "Count lines")
+	words := flag.Bool("w", false, "Count words")
+	bytes := flag.Bool("c", false, "Count bytes")
+	runes := flag.Bool("m", false, "Count characters (UTF-8 runes)")
+	maxLine := flag.Bool("L", false, "Print the length of the longest line")
+	tokenizer := flag.String("tokenizer", "whitespace", "How words are split: whitespace, unicode or delims")
+	delims := flag.String("delims", "", "Characters splitting words, besides white space, with -tokenizer delims")
+	format := flag.String("format", "table", "Output format: table, csv or json")
+	filesFrom := flag.String("files0-from", "", "Read the NUL or newline separated names of the files to count from this file, - for STDIN")
+	workers := flag.Int("workers", runtime.NumCPU(), "Number of files to count concurrently")
+	// Word frequency options
+	freq := flag.Bool("freq", false, "Report the most frequent words")
+	top := flag.Int("top", 10, "Number of words to report with -freq, 0 for all")
+	fold := flag.Bool("fold", false, "Ignore case when counting word frequency")
+	strip := flag.Bool("strip", false, "Strip punctuation around words when counting word frequency")
+	stats := flag.Bool("stats", false, "Report character statistics: ASCII, invalid UTF-8, control characters and line endings")
+	// Line length options
+	lineLen := flag.Bool("linelen", false, "Report the distribution of line lengths and the longest line")
+	over := flag.Int("over", 0, "Report the lines longer than this many characters with -linelen")
+	// Source code options
+	code := flag.Bool("code", false, "Count code, comment and blank lines per language")
+	lang := flag.String("lang", "", "Language of the input with -code, instead of detecting it by file extension")
+	// Recursive mode options. -include and -exclude can be given several times
+	recursive := flag.Bool("r", false, "Count the files below the given directories, or the current one")
+	include := patterns{}
+	flag.Var(&include, "include", "Only count files matching this glob with -r (repeatable)")
+	exclude := patterns{}
+	flag.Var(&exclude, "exclude", "Skip files and directories matching this glob with -r, ** matches any path (repeatable)")
+	// Follow mode options
+	follow := flag.Bool("follow", false, "Keep counting the lines and words of a growing file, like tail -f")
+	interval := flag.Duration("interval", time.Second, "How often to report the counts with -follow")
+	// Pattern matching options. -e can be given several times
+	pats := patterns{}
+	flag.Var(&pats, "e", "Count matches of this regular expression (repeatable)")
+	matchLines := flag.Bool("match-lines", false, "Count lines matching each -e pattern instead of every match")
 
 	// Parsing the flags provided by the user
 	flag.Parse()
 
-	// Calling the count function to count the number of words (or lines)
-	// received from the Standard Input and printing it out
-	fmt.Println(count(os.Stdin, *lines))
+	c := config{
+		lines:   *lines,
+		words:   *words,
+		runes:   *runes,
+		bytes:   *bytes,
+		maxLine: *maxLine,
+		workers: *workers,
+		format:  *format,
+		freq:    *freq,
+		top:     *top,
+		fold:    *fold,
+		strip:   *strip,
+
+		patterns:   pats,
+		matchLines: *matchLines,
+
+		follow:   *follow,
+		interval: *interval,
+
+		recursive: *recursive,
+		include:   include,
+		exclude:   exclude,
+
+		code: *code,
+		lang: *lang,
+
+		tokenizer: *tokenizer,
+		delims:    *delims,
+
+		stats:   *stats,
+		lineLen: *lineLen,
+		over:    *over,
+
+		filesFrom: *filesFrom,
+	}
+
+	// Counting words is the default when no mode was selected
+	if !c.lines && !c.words && !c.runes && !c.bytes && !c.maxLine {
+		c.words = true
+	}
+
+	// Any remaining arguments are the names of the files to count. When there are none
+	// the Standard Input is counted instead
+	if err := run(flag.Args(), c, os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
 
-func count(r io.Reader, countLines bool) int {
-	// A scanner is used to read text from a Reader (such as files)
-	scanner := bufio.NewScanner(r)
+// run counts every file in filenames using the mode selected in cfg. A file that
+// cannot be counted is reported to errOut and skipped, so the remaining files are still
+// counted, but run returns an error at the end so the caller can exit with a non-zero
+// status.
+func run(filenames []string, cfg config, in io.Reader, out, errOut io.Writer) error {
+	if cfg.workers < 1 {
+		return fmt.Errorf("%w: %d", ErrInvalidWorkers, cfg.workers)
+	}
+
+	// Check the format before reading any file
+	switch cfg.format {
+	case "table", "csv", "json":
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidFormat, cfg.format)
+	}
+
+	tok, err := newTokenizer(cfg.tokenizer, cfg.delims)
+	if err != nil {
+		return err
+	}
+
+	// Only one of the modes replacing the counts can be used at a time
+	modes := []string{}
+	if cfg.freq {
+		modes = append(modes, "-freq")
+	}
+	if len(cfg.patterns) > 0 {
+		modes = append(modes, "-e")
+	}
+	if cfg.code {
+		modes = append(modes, "-code")
+	}
+	if cfg.stats {
+		modes = append(modes, "-stats")
+	}
+	if cfg.lineLen {
+		modes = append(modes, "-linelen")
+	}
+	if cfg.follow {
+		modes = append(modes, "-follow")
+	}
 
-	// If the count lines flag is not set, we want to count words
-	if !countLines {
-		// Define the scanner split type to words (by default is split by lines)
-		scanner.Split(bufio.ScanWords)
+	if len(modes) > 1 {
+		return fmt.Errorf("%w: %s", ErrConflictingModes, strings.Join(modes, ", "))
 	}
 
-	// Defining a counter
-	wc := 0
+	// Follow mode runs until the user interrupts it with Ctrl+C. It always prints
+	// the lines and words with their rates, so it only supports the table format
+	if cfg.follow {
+		if len(filenames) != 1 {
+			return fmt.Errorf("%w: -follow requires exactly one file", ErrInvalidFiles)
+		}
+
+		if cfg.format != "table" {
+			return fmt.Errorf("%w: -follow only supports the table format", ErrInvalidFormat)
+		}
+
+		if cfg.bytes || cfg.runes || cfg.maxLine {
+			return fmt.Errorf("%w: -follow only counts lines and words, it cannot be combined with -c, -m or -L",
+				ErrConflictingModes)
+		}
 
-	// For every word or line scanned, increment the counter
-	for scanner.Scan() {
-		wc++
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		return runFollow(ctx, filenames[0], cfg.interval, tok, out, errOut)
 	}
 
-	// Return the total
-	return wc
+	// Read the file names from a list instead of the arguments
+	if cfg.filesFrom != "" {
+		if len(filenames) > 0 {
+			return fmt.Errorf("%w: file names cannot be combined with -files0-from", ErrInvalidFiles)
+		}
+
+		filenames, err = readFileList(cfg.filesFrom, in)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Expand the directories into the files below them. Without file names the
+	// current directory is counted
+	var roots []string
+	if cfg.recursive {
+		if len(filenames) == 0 && cfg.filesFrom == "" {
+			filenames = []string{"."}
+		}
+
+		w, err := walkDirs(filenames, cfg.include, cfg.exclude)
+		if err != nil {
+			return err
+		}
+
+		filenames, roots, cfg.walkErrs = w.files, w.roots, w.failed
+	}
+
+	// Without file names the Standard Input is counted instead, unless the names
+	// came from a list or from walking directories and there were none
+	stdin := len(filenames) == 0 && !cfg.recursive && cfg.filesFrom == ""
+	if stdin {
+		filenames = []string{"-"}
+	}
+
+	switch {
+	case cfg.freq:
+		return runFreq(filenames, cfg, tok, in, out, errOut)
+	case len(cfg.patterns) > 0:
+		return runMatch(filenames, stdin, cfg, in, out, errOut)
+	case cfg.code:
+		return runCode(filenames, stdin, cfg, in, out, errOut)
+	case cfg.stats:
+		return runStats(filenames, stdin, cfg, in, out, errOut)
+	case cfg.lineLen:
+		return runLineLen(filenames, stdin, cfg, in, out, errOut)
+	}
+
+	return runCount(filenames, roots, stdin, cfg, tok, in, out, errOut)
+}
+
+// newTokenizer returns the tokenizer with the given name
+func newTokenizer(name, delims string) (counter.Tokenizer, error) {
+	switch name {
+	case "whitespace":
+		return counter.Whitespace, nil
+	case "unicode":
+		return counter.Unicode, nil
+	case "delims":
+		return counter.Delimiters(delims), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTokenizer, name)
+	}
+}
+
+// runCount reports the selected counts with one result per file followed by a
+// subtotal for each of the walked directories and their subdirectories, and a
+// total. roots holds the directory each file was found walking, if any.
+// When reading only the Standard Input there is a single result without a name
+// and no total
+func runCount(filenames, roots []string, stdin bool, cfg config, tok counter.Tokenizer, in io.Reader, out, errOut io.Writer) error {
+	results := make([]counter.Counts, len(filenames))
+
+	// The files are counted concurrently but the results are kept in the same
+	// order as the arguments, so the output and the total are always the same
+	errs := process(filenames, cfg, in, func(i int, r io.Reader) error {
+		c, err := counter.CountWith(r, tok)
+		results[i] = c
+		return err
+	})
+
+	rep := report{columns: columns(cfg)}
+
+	if cfg.recursive && !stdin {
+		rep.subtotals = dirSubtotals(filenames, roots, results, errs, cfg)
+	}
+
+	total := counter.Counts{}
+
+	return reportFiles(out, errOut, rep, cfg.format, filenames, stdin, errs,
+		func(i int, name string) row {
+			total.Add(results[i])
+			return row{name: name, values: values(results[i], cfg)}
+		},
+		func() row {
+			return row{name: "total", values: values(total, cfg)}
+		})
 }
@@ -2,30 +2,200 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"testing"
+	"time"
 )
 
-// TestCountWords tests the count function set to count words
-func TestCountWords(t *testing.T) {
-	b := bytes.NewBufferString("word1 word2 word3 word4\n")
-
-	exp := 4
-
-	res := count(b, false)
-
-	if res != exp {
-		t.Errorf("Expected %d, got %d instead.\n", exp, res)
-	}
+// TestRun tests the run function counting files given as arguments
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name      string
+		files     []string
+		cfg       config
+		exp       string
+		expErrOut string
+		expErr    error
+	}{
+		{name: "Stdin", files: []string{}, cfg: config{format: "table", tokenizer: "whitespace", workers: 1, words: true},
+			exp: "2\n",
+		},
+		{name: "OneFile", files: []string{"./testdata/file1.txt"}, cfg: config{format: "table", tokenizer: "whitespace", workers: 1, words: true},
+			exp: "4 ./testdata/file1.txt\n4 total\n",
+		},
+		{name: "MultiFilesWords", files: []string{"./testdata/file1.txt", "./testdata/file2.txt"}, cfg: config{format: "table", tokenizer: "whitespace", workers: 1, words: true},
+			exp: "4 ./testdata/file1.txt\n6 ./testdata/file2.txt\n10 total\n",
+		},
+		{name: "MultiFilesLines", files: []string{"./testdata/file1.txt", "./testdata/file2.txt"}, cfg: config{format: "table", tokenizer: "whitespace", workers: 1, lines: true},
+			exp: "2 ./testdata/file1.txt\n3 ./testdata/file2.txt\n5 total\n",
+		},
+		{name: "MultiModes", files: []string{"./testdata/file1.txt", "./testdata/file2.txt"},
+			cfg: config{format: "table", tokenizer: "whitespace", workers: 1, lines: true, words: true, runes: true, bytes: true},
+			exp: "2 4 24 24 ./testdata/file1.txt\n3 6 28 28 ./testdata/file2.txt\n5 10 52 52 total\n",
+		},
+		{name: "MaxLineLength", files: []string{"./testdata/file1.txt", "./testdata/file2.txt"},
+			cfg: config{format: "table", tokenizer: "whitespace", workers: 1, maxLine: true},
+			exp: "17 ./testdata/file1.txt\n15 ./testdata/file2.txt\n17 total\n",
+		},
+		{name: "StdinModes", files: []string{}, cfg: config{format: "table", tokenizer: "whitespace", workers: 1, lines: true, bytes: true},
+			exp: "1 11\n",
+		},
+		{name: "ManyFilesWorkers",
+			files: []string{"./testdata/file2.txt", "./testdata/file1.txt", "./testdata/file2.txt", "./testdata/file1.txt", "./testdata/file2.txt"},
+			cfg:   config{format: "table", tokenizer: "whitespace", workers: 3, lines: true},
+			exp:   "3 ./testdata/file2.txt\n2 ./testdata/file1.txt\n3 ./testdata/file2.txt\n2 ./testdata/file1.txt\n3 ./testdata/file2.txt\n13 total\n",
+		},
+		{name: "InvalidWorkers", files: []string{"./testdata/file1.txt"}, cfg: config{format: "table", tokenizer: "whitespace", workers: 0, words: true},
+			exp: "", expErr: ErrInvalidWorkers,
+		},
+		{name: "Freq", files: []string{"./testdata/freq.txt", "./testdata/freq.txt"},
+			cfg: config{format: "table", tokenizer: "whitespace", workers: 2, freq: true, top: 3, fold: true, strip: true},
+			exp: "10 the\n4 dog\n4 end\n",
+		},
+		{name: "FreqStdin", files: []string{}, cfg: config{format: "table", tokenizer: "whitespace", workers: 1, freq: true, top: 0},
+			exp: "1 from\n1 stdin\n",
+		},
+		{name: "Match", files: []string{"./testdata/freq.txt", "./testdata/file1.txt"},
+			cfg: config{format: "table", tokenizer: "whitespace", workers: 2, patterns: []string{`(?i)the`, `word\d`}},
+			exp: "5 0 ./testdata/freq.txt\n0 3 ./testdata/file1.txt\n5 3 total\n",
+		},
+		{name: "MatchLinesStdin", files: []string{}, cfg: config{format: "table", tokenizer: "whitespace", workers: 1, patterns: []string{`o`}, matchLines: true},
+			exp: "1\n",
+		},
+		{name: "InvalidPattern", files: []string{"./testdata/file1.txt"}, cfg: config{format: "table", tokenizer: "whitespace", workers: 1, patterns: []string{`(`}},
+			exp: "", expErr: ErrInvalidPattern,
+		},
+		{name: "ConflictingModes", files: []string{"./testdata/file1.txt"},
+			cfg: config{format: "table", tokenizer: "whitespace", workers: 1, freq: true, patterns: []string{`a`}},
+			exp: "", expErr: ErrConflictingModes,
+		},
+		{name: "StdinTwice", files: []string{"-", "./testdata/file1.txt", "-"},
+			cfg: config{format: "table", tokenizer: "whitespace", workers: 3, lines: true, words: true},
+			exp: "1 2 -\n2 4 ./testdata/file1.txt\n0 0 -\n3 6 total\n",
+		},
+		{name: "FormatCSV", files: []string{"./testdata/file1.txt", "./testdata/file2.txt"},
+			cfg: config{format: "csv", tokenizer: "whitespace", workers: 1, lines: true, words: true},
+			exp: "name,lines,words\n./testdata/file1.txt,2,4\n./testdata/file2.txt,3,6\ntotal,5,10\n",
+		},
+		{name: "FormatInvalid", files: []string{"./testdata/file1.txt"}, cfg: config{format: "xml", tokenizer: "whitespace", workers: 1, words: true},
+			exp: "", expErr: ErrInvalidFormat,
+		},
+		{name: "Compressed", files: []string{"./testdata/file2.txt", "./testdata/file2.txt.gz", "./testdata/file2.txt.bz2"},
+			cfg: config{format: "table", tokenizer: "whitespace", workers: 2, lines: true, words: true},
+			exp: "3 6 ./testdata/file2.txt\n3 6 ./testdata/file2.txt.gz\n3 6 ./testdata/file2.txt.bz2\n9 18 total\n",
+		},
+		{name: "FollowManyFiles", files: []string{"./testdata/file1.txt", "./testdata/file2.txt"},
+			cfg: config{format: "table", tokenizer: "whitespace", workers: 1, follow: true, interval: time.Second},
+			exp: "", expErr: ErrInvalidFiles,
+		},
+		{name: "FollowConflictingModes", files: []string{"./testdata/file1.txt"},
+			cfg: config{format: "table", tokenizer: "whitespace", workers: 1, follow: true, interval: time.Second, freq: true},
+			exp: "", expErr: ErrConflictingModes,
+		},
+		{name: "FollowFormat", files: []string{"./testdata/file1.txt"},
+			cfg: config{format: "json", tokenizer: "whitespace", workers: 1, follow: true, interval: time.Second},
+			exp: "", expErr: ErrInvalidFormat,
+		},
+		{name: "FollowBytes", files: []string{"./testdata/file1.txt"},
+			cfg: config{format: "table", tokenizer: "whitespace", workers: 1, follow: true, interval: time.Second, bytes: true},
+			exp: "", expErr: ErrConflictingModes,
+		},
+		{name: "Code", files: []string{"./testdata/code/hello.py", "./testdata/file1.txt", "./testdata/code/hello.go"},
+			cfg: config{format: "csv", tokenizer: "whitespace", workers: 2, code: true},
+			exp: "name,files,code,comment,blank\nGo,1,4,4,1\nPython,1,2,1,2\ntotal,2,6,5,3\n",
+		},
+		{name: "CodeStdin", files: []string{}, cfg: config{format: "table", tokenizer: "whitespace", workers: 1, code: true, lang: "shell"},
+			exp: "1 1 0 0 Shell\n1 1 0 0 total\n",
+		},
+		{name: "CodeStdinNoLang", files: []string{}, cfg: config{format: "table", tokenizer: "whitespace", workers: 1, code: true},
+			exp: "", expErr: ErrInvalidLanguage,
+		},
+		{name: "TokenizerDelims", files: []string{"./testdata/file1.txt"},
+			cfg: config{format: "table", tokenizer: "delims", delims: "d", workers: 1, words: true},
+			exp: "7 ./testdata/file1.txt\n7 total\n",
+		},
+		{name: "TokenizerInvalid", files: []string{"./testdata/file1.txt"},
+			cfg: config{format: "table", tokenizer: "regex", workers: 1, words: true},
+			exp: "", expErr: ErrInvalidTokenizer,
+		},
+		{name: "Stats", files: []string{"./testdata/stats.txt", "./testdata/file1.txt"},
+			cfg: config{format: "table", tokenizer: "whitespace", workers: 2, stats: true},
+			exp: "16 1 1 0 2 0 0 1 11 ./testdata/stats.txt\n24 0 0 0 0 2 0 1 - ./testdata/file1.txt\n40 1 1 0 2 2 0 2 - total\n",
+		},
+		{name: "StatsJSONStdin", files: []string{}, cfg: config{format: "json", tokenizer: "whitespace", workers: 1, stats: true},
+			exp: `{
+  "results": [
+    {
+      "ascii": 11,
+      "control": 0,
+      "cr": 0,
+      "crlf": 0,
+      "invalid": 0,
+      "invalid_offsets": [],
+      "lf": 1,
+      "non_ascii": 0,
+      "trailing_newline": 1
+    }
+  ]
 }
+`,
+		},
+		{name: "LineLen", files: []string{"./testdata/file1.txt", "./testdata/file2.txt"},
+			cfg: config{format: "table", tokenizer: "whitespace", workers: 2, lineLen: true},
+			exp: "2 5 17 11 5 17 17 17 5 17 11 5 17 17 17 1 ./testdata/file1.txt\n" +
+				"3 3 15 8 7 15 15 15 3 15 8 7 15 15 15 2 ./testdata/file2.txt\n" +
+				"5 3 17 9 7 17 17 17 3 17 9 7 17 17 17 0 total\n",
+		},
+		{name: "LineLenMean", files: []string{"./testdata/file1.txt", "./testdata/file2.txt"},
+			cfg: config{format: "csv", tokenizer: "whitespace", workers: 2, lineLen: true},
+			exp: "name,lines,min_bytes,max_bytes,mean_bytes,p50_bytes,p90_bytes,p95_bytes,p99_bytes," +
+				"min_runes,max_runes,mean_runes,p50_runes,p90_runes,p95_runes,p99_runes,longest_line\n" +
+				"./testdata/file1.txt,2,5,17,11,5,17,17,17,5,17,11,5,17,17,17,1\n" +
+				"./testdata/file2.txt,3,3,15,8.33,7,15,15,15,3,15,8.33,7,15,15,15,2\n" +
+				"total,5,3,17,9.4,7,17,17,17,3,17,9.4,7,17,17,17,0\n",
+		},
+		{name: "LineLenOver", files: []string{"./testdata/file1.txt", "./testdata/file2.txt"},
+			cfg: config{format: "csv", tokenizer: "whitespace", workers: 2, lineLen: true, over: 10},
+			exp: "name,bytes,runes\n./testdata/file1.txt:1,17,17\n./testdata/file2.txt:2,15,15\n",
+		},
+		{name: "FilesFrom", files: []string{},
+			cfg: config{format: "table", tokenizer: "whitespace", workers: 2, lines: true, filesFrom: "./testdata/files0.list"},
+			exp: "2 ./testdata/file1.txt\n3 ./testdata/file2.txt\n5 total\n",
+		},
+		{name: "FilesFromWithArgs", files: []string{"./testdata/file1.txt"},
+			cfg: config{format: "table", tokenizer: "whitespace", workers: 1, lines: true, filesFrom: "./testdata/files0.list"},
+			exp: "", expErr: ErrInvalidFiles,
+		},
+		{name: "MissingFile", files: []string{"./testdata/file1.txt", "./testdata/fakefile.txt", "./testdata/file2.txt"},
+			cfg:       config{format: "table", tokenizer: "whitespace", workers: 1, words: true},
+			exp:       "4 ./testdata/file1.txt\n6 ./testdata/file2.txt\n10 total\n",
+			expErrOut: "cannot open file: open ./testdata/fakefile.txt: no such file or directory\n",
+			expErr:    ErrFilesFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			in := bytes.NewBufferString("from stdin\n")
 
-func TestCountLines(t *testing.T) {
-	b := bytes.NewBufferString("word 1 word2 word3\nline2\nline3 word1")
+			err := run(tc.files, tc.cfg, in, &out, &errOut)
 
-	exp := 3
+			if tc.expErr != nil {
+				if !errors.Is(err, tc.expErr) {
+					t.Errorf("Expected error %q, got %q instead.\n", tc.expErr, err)
+				}
+			} else if err != nil {
+				t.Errorf("Unexpected error: %q\n", err)
+			}
 
-	res := count(b, true)
+			if out.String() != tc.exp {
+				t.Errorf("Expected %q, got %q instead.\n", tc.exp, out.String())
+			}
 
-	if res != exp {
-		t.Errorf("Expected %d, got %d instead.\n", exp, res)
+			if errOut.String() != tc.expErrOut {
+				t.Errorf("Expected error output %q, got %q instead.\n", tc.expErrOut, errOut.String())
+			}
+		})
 	}
 }
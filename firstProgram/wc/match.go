@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"pragprog.com/rggo/firstProgram/wc/counter"
+)
+
+// patterns implements the flag.Value interface so flags such as -e can be given
+// several times, collecting every pattern
+type patterns []string
+
+func (p *patterns) String() string {
+	return strings.Join(*p, ", ")
+}
+
+func (p *patterns) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
+// runMatch reports the number of matches of each pattern, with one result per
+// file followed by a total. Each result has one count per pattern, in the same order
+// the patterns were given. When reading only the Standard Input there is a single
+// result without a name and no total
+func runMatch(filenames []string, stdin bool, cfg config, in io.Reader, out, errOut io.Writer) error {
+	res := make([]*regexp.Regexp, 0, len(cfg.patterns))
+
+	for _, p := range cfg.patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidPattern, err)
+		}
+
+		res = append(res, re)
+	}
+
+	results := make([][]int, len(filenames))
+
+	errs := process(filenames, cfg, in, func(i int, r io.Reader) error {
+		m, err := counter.Matches(r, res, cfg.matchLines)
+		results[i] = m
+		return err
+	})
+
+	rep := report{columns: cfg.patterns}
+	total := make([]int, len(res))
+
+	return reportFiles(out, errOut, rep, cfg.format, filenames, stdin, errs,
+		func(i int, name string) row {
+			for j, n := range results[i] {
+				total[j] += n
+			}
+
+			return row{name: name, values: results[i]}
+		},
+		func() row {
+			return row{name: "total", values: total}
+		})
+}
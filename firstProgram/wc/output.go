@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// row holds the values of a single result, such as the counts for one file
+type row struct {
+	// name identifies the row, usually the file name. It is empty when the
+	// Standard Input was read
+	name   string
+	values []int
+	// floats holds the exact value of the columns that are not whole numbers, such
+	// as a mean, by their index in values. The table shows the rounded value in
+	// values so its columns stay integers, while csv and json show this one
+	floats map[int]float64
+	// list holds the values of the report list column, if it has one
+	list []int64
+}
+
+// report holds the results of a run, decoupled from the format used to print it
+type report struct {
+	// columns holds the name of each value, in the same order as the row values
+	columns []string
+	// listColumn is the name of an extra column holding a list of values for each
+	// row, or empty if there's none
+	listColumn string
+	rows       []row
+	// subtotals holds the rows adding up groups of rows, such as directories
+	subtotals []row
+	// total holds the totals row, or nil if the report has no total
+	total *row
+}
+
+// printReport prints the report to out using the given format: table, csv or json
+func printReport(out io.Writer, rep report, format string) error {
+	switch format {
+	case "table":
+		return printTable(out, rep)
+	case "csv":
+		return printCSV(out, rep)
+	case "json":
+		return printJSON(out, rep)
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidFormat, format)
+	}
+}
+
+// all returns every row of the report in the order they are printed: the results,
+// the subtotals and then the total
+func (rep report) all() []row {
+	rows := append([]row{}, rep.rows...)
+	rows = append(rows, rep.subtotals...)
+
+	if rep.total != nil {
+		rows = append(rows, *rep.total)
+	}
+
+	return rows
+}
+
+// printTable prints each row with its values separated by spaces followed by its name.
+// This is the default format, compatible with the output of previous versions
+func printTable(out io.Writer, rep report) error {
+	rows := rep.all()
+
+	for _, r := range rows {
+		fields := make([]string, 0, len(r.values)+1)
+
+		for _, v := range r.values {
+			fields = append(fields, strconv.Itoa(v))
+		}
+
+		if rep.listColumn != "" {
+			// Use a dash for an empty list so the columns are never empty
+			fields = append(fields, formatList(r.list, ",", "-"))
+		}
+
+		if r.name != "" {
+			fields = append(fields, r.name)
+		}
+
+		if _, err := fmt.Fprintln(out, strings.Join(fields, " ")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printCSV prints a header with the column names followed by one record per row.
+// The name comes first so tools like colStats can use the value columns directly
+func printCSV(out io.Writer, rep report) error {
+	cw := csv.NewWriter(out)
+
+	header := append([]string{"name"}, rep.columns...)
+	if rep.listColumn != "" {
+		header = append(header, rep.listColumn)
+	}
+
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	rows := rep.all()
+
+	for _, r := range rows {
+		record := []string{r.name}
+
+		for i, v := range r.values {
+			if f, ok := r.floats[i]; ok {
+				record = append(record, strconv.FormatFloat(f, 'f', -1, 64))
+				continue
+			}
+
+			record = append(record, strconv.Itoa(v))
+		}
+
+		if rep.listColumn != "" {
+			record = append(record, formatList(r.list, " ", ""))
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	// csv.Writer is buffered, Flush writes any pending data and Error reports
+	// any problem found writing
+	cw.Flush()
+	return cw.Error()
+}
+
+// printJSON prints an object with the list of results, and the subtotals and the
+// total, if any.
+// Each result is an object with the name and a key for each column
+func printJSON(out io.Writer, rep report) error {
+	obj := func(r row) map[string]any {
+		m := map[string]any{}
+
+		if r.name != "" {
+			m["name"] = r.name
+		}
+
+		for i, c := range rep.columns {
+			if f, ok := r.floats[i]; ok {
+				m[c] = f
+				continue
+			}
+
+			m[c] = r.values[i]
+		}
+
+		if rep.listColumn != "" && r.list != nil {
+			m[rep.listColumn] = r.list
+		}
+
+		return m
+	}
+
+	doc := map[string]any{}
+
+	results := make([]map[string]any, 0, len(rep.rows))
+	for _, r := range rep.rows {
+		results = append(results, obj(r))
+	}
+	doc["results"] = results
+
+	if len(rep.subtotals) > 0 {
+		subtotals := make([]map[string]any, 0, len(rep.subtotals))
+		for _, r := range rep.subtotals {
+			subtotals = append(subtotals, obj(r))
+		}
+		doc["subtotals"] = subtotals
+	}
+
+	if rep.total != nil {
+		doc["total"] = obj(*rep.total)
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(doc)
+}
+
+// formatList joins the list values with sep, or returns empty if there are none
+func formatList(list []int64, sep, empty string) string {
+	if len(list) == 0 {
+		return empty
+	}
+
+	fields := make([]string, 0, len(list))
+	for _, v := range list {
+		fields = append(fields, strconv.FormatInt(v, 10))
+	}
+
+	return strings.Join(fields, sep)
+}
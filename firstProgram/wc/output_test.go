@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestPrintReport tests the report is printed in every supported format
+func TestPrintReport(t *testing.T) {
+	rep := report{
+		columns: []string{"lines", "words"},
+		rows: []row{
+			{name: "file1.txt", values: []int{2, 4}},
+			{name: "file,2.txt", values: []int{3, 6}},
+		},
+		total: &row{name: "total", values: []int{5, 10}},
+	}
+
+	stdin := report{
+		columns: []string{"words"},
+		rows:    []row{{values: []int{7}}},
+	}
+
+	testCases := []struct {
+		name   string
+		rep    report
+		format string
+		exp    string
+		expErr error
+	}{
+		{name: "Table", rep: rep, format: "table",
+			exp: "2 4 file1.txt\n3 6 file,2.txt\n5 10 total\n",
+		},
+		{name: "TableStdin", rep: stdin, format: "table",
+			exp: "7\n",
+		},
+		{name: "CSV", rep: rep, format: "csv",
+			exp: "name,lines,words\nfile1.txt,2,4\n\"file,2.txt\",3,6\ntotal,5,10\n",
+		},
+		{name: "CSVStdin", rep: stdin, format: "csv",
+			exp: "name,words\n,7\n",
+		},
+		{name: "JSON", rep: rep, format: "json",
+			exp: `{
+  "results": [
+    {
+      "lines": 2,
+      "name": "file1.txt",
+      "words": 4
+    },
+    {
+      "lines": 3,
+      "name": "file,2.txt",
+      "words": 6
+    }
+  ],
+  "total": {
+    "lines": 5,
+    "name": "total",
+    "words": 10
+  }
+}
+`,
+		},
+		{name: "JSONStdin", rep: stdin, format: "json",
+			exp: `{
+  "results": [
+    {
+      "words": 7
+    }
+  ]
+}
+`,
+		},
+		{name: "InvalidFormat", rep: rep, format: "xml",
+			expErr: ErrInvalidFormat,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			err := printReport(&out, tc.rep, tc.format)
+
+			if tc.expErr != nil {
+				if !errors.Is(err, tc.expErr) {
+					t.Errorf("Expected error %q, got %q instead.\n", tc.expErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if out.String() != tc.exp {
+				t.Errorf("Expected %q, got %q instead.\n", tc.exp, out.String())
+			}
+		})
+	}
+}
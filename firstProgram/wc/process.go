@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// process calls fn for every file in filenames using a pool of cfg.workers goroutines.
+// fn receives the index of the file so it can store its result in a slice at the same
+// position without further synchronization, keeping the results in the same order as
+// the file names. The file named "-" is read from in. When it's given more than once
+// they are read one after the other, in order, so as in coreutils the first one reads
+// the whole input and the others find it empty. fn is not called for the files that
+// failed while walking the directories, which get the error found then.
+// The returned slice holds the error for each file, if any
+func process(filenames []string, cfg config, in io.Reader, fn func(i int, r io.Reader) error) []error {
+	errs := make([]error, len(filenames))
+
+	// The jobs channel receives the index of the next file to process
+	jobs := make(chan int)
+
+	wg := sync.WaitGroup{}
+
+	// Reading in from several workers at once would split the input between them,
+	// so the files named "-" are processed by a goroutine of their own
+	stdin := []int{}
+	for i, fname := range filenames {
+		if fname == "-" {
+			stdin = append(stdin, i)
+		}
+	}
+
+	if len(stdin) > 0 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for _, idx := range stdin {
+				errs[idx] = processFile(filenames[idx], in, func(r io.Reader) error {
+					return fn(idx, r)
+				})
+			}
+		}()
+	}
+
+	for i := 0; i < cfg.workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for idx := range jobs {
+				if err, ok := cfg.walkErrs[filenames[idx]]; ok {
+					errs[idx] = err
+					continue
+				}
+
+				errs[idx] = processFile(filenames[idx], in, func(r io.Reader) error {
+					return fn(idx, r)
+				})
+			}
+		}()
+	}
+
+	for i, fname := range filenames {
+		if fname != "-" {
+			jobs <- i
+		}
+	}
+
+	// Closing the channel tells the workers there are no more files to process
+	close(jobs)
+
+	wg.Wait()
+
+	return errs
+}
+
+// processFile opens the named file and calls fn to read its contents. Compressed
+// files are decompressed first, so fn always reads the original content
+func processFile(fname string, in io.Reader, fn func(r io.Reader) error) error {
+	if fname == "-" {
+		r, err := decompress(in)
+		if err != nil {
+			return err
+		}
+
+		return fn(r)
+	}
+
+	f, err := os.Open(fname)
+	if err != nil {
+		return fmt.Errorf("cannot open file: %w", err)
+	}
+	defer f.Close()
+
+	r, err := decompress(f)
+	if err != nil {
+		return fmt.Errorf("cannot read file %s: %w", fname, err)
+	}
+
+	if err := fn(r); err != nil {
+		return fmt.Errorf("cannot read file %s: %w", fname, err)
+	}
+
+	return nil
+}
+
+// eachCounted calls fn with the index of every file counted, in order. The error
+// of every file that failed is reported to errOut instead, so the problem is
+// reported but the remaining files are still counted
+func eachCounted(errs []error, errOut io.Writer, fn func(i int)) {
+	for i, err := range errs {
+		if err != nil {
+			fmt.Fprintln(errOut, err)
+			continue
+		}
+
+		fn(i)
+	}
+}
+
+// reportFiles prints rep with one row per file counted followed by the total, as
+// returned by rowOf and total. rowOf is called in order with the index and name of
+// every file counted, so it can also add its results to the total. The files that
+// failed are reported to errOut and skipped.
+// When reading only the Standard Input there is a single row without a name and no
+// total, and if reading fails the error is returned instead
+func reportFiles(out, errOut io.Writer, rep report, format string, filenames []string, stdin bool,
+	errs []error, rowOf func(i int, name string) row, total func() row) error {
+	if stdin {
+		if errs[0] != nil {
+			return errs[0]
+		}
+
+		rep.rows = []row{rowOf(0, "")}
+
+		return printReport(out, rep, format)
+	}
+
+	eachCounted(errs, errOut, func(i int) {
+		rep.rows = append(rep.rows, rowOf(i, filenames[i]))
+	})
+
+	t := total()
+	rep.total = &t
+
+	return printResults(out, rep, format, errs)
+}
+
+// printResults prints the report, and then returns an error wrapping ErrFilesFailed
+// if any of the files failed
+func printResults(out io.Writer, rep report, format string, errs []error) error {
+	if err := printReport(out, rep, format); err != nil {
+		return err
+	}
+
+	return failures(errs)
+}
+
+// failures returns an error wrapping ErrFilesFailed if any of errs is set
+func failures(errs []error) error {
+	failed := 0
+
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d", ErrFilesFailed, failed, len(errs))
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io"
+
+	"pragprog.com/rggo/firstProgram/wc/counter"
+)
+
+// statsColumns holds the names of the values reported in stats mode
+var statsColumns = []string{"ascii", "non_ascii", "invalid", "control", "crlf", "lf", "cr", "trailing_newline"}
+
+// runStats reports the character statistics of every file, with one result per
+// file followed by a total. The offsets of the first invalid UTF-8 sequences of
+// each file are reported in an extra column. When reading only the Standard Input
+// there is a single result without a name and no total
+func runStats(filenames []string, stdin bool, cfg config, in io.Reader, out, errOut io.Writer) error {
+	results := make([]counter.Stats, len(filenames))
+
+	errs := process(filenames, cfg, in, func(i int, r io.Reader) error {
+		s, err := counter.Inspect(r)
+		results[i] = s
+		return err
+	})
+
+	rep := report{columns: statsColumns, listColumn: "invalid_offsets"}
+	total := make([]int, len(statsColumns))
+
+	return reportFiles(out, errOut, rep, cfg.format, filenames, stdin, errs,
+		func(i int, name string) row {
+			r := statsRow(name, results[i])
+
+			for j, v := range r.values {
+				total[j] += v
+			}
+
+			return r
+		},
+		func() row {
+			// The total of trailing_newline is the number of files ending with a newline
+			return row{name: "total", values: total}
+		})
+}
+
+// statsRow returns the report row for the stats, in the same order as statsColumns
+func statsRow(name string, s counter.Stats) row {
+	trailing := 0
+	if s.TrailingNewline {
+		trailing = 1
+	}
+
+	return row{
+		name:   name,
+		values: []int{s.ASCII, s.NonASCII, s.Invalid, s.Control, s.CRLF, s.LF, s.CR, trailing},
+		list:   s.InvalidOffsets,
+	}
+}
@@ -0,0 +1,9 @@
+// Package hello says hello
+package hello
+
+/*
+Hello returns a greeting
+*/
+func Hello() string {
+	return "hello" // greeting
+}
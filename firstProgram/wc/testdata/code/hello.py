@@ -0,0 +1,5 @@
+#!/usr/bin/env python
+
+
+def hello():
+    return "hello"
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"pragprog.com/rggo/firstProgram/wc/counter"
+)
+
+// binaryCheckSize is how many bytes are inspected to decide if a file is binary,
+// the same amount used by git
+const binaryCheckSize = 8000
+
+// walked holds the files to count found by walkDirs
+type walked struct {
+	files []string
+	// roots holds the directory each file was found walking, in the same order as
+	// files, or an empty string for the names kept as they were given
+	roots []string
+	// failed holds the errors found walking the directories, by path. The paths are
+	// in files too, so the errors are reported when counting them
+	failed map[string]error
+}
+
+// walkDirs expands every directory in filenames into the files below it, keeping
+// any other name as it is. Files are included when they match any of the include
+// globs, or all of them if there are none, and don't match any of the exclude globs.
+// Binary files found walking a directory are skipped. A directory that can't be
+// read is skipped too, but its path is kept with the error so it's reported when
+// counting, the same as a file that can't be read
+func walkDirs(filenames, include, exclude []string) (walked, error) {
+	for _, p := range append(include, exclude...) {
+		if err := validGlob(p); err != nil {
+			return walked{}, err
+		}
+	}
+
+	w := walked{files: []string{}, roots: []string{}, failed: map[string]error{}}
+
+	for _, name := range filenames {
+		info, err := os.Stat(name)
+		if err != nil || !info.IsDir() {
+			// Not a directory, or it doesn't exist: keep it so the error is reported
+			// when counting it
+			w.files = append(w.files, name)
+			w.roots = append(w.roots, "")
+			continue
+		}
+
+		err = filepath.WalkDir(name, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Carry on with the rest of the tree. WalkDir doesn't walk the
+				// directory it failed to read any further
+				w.files = append(w.files, p)
+				w.roots = append(w.roots, "")
+				w.failed[p] = fmt.Errorf("cannot read directory: %w", err)
+
+				return nil
+			}
+
+			// Globs are always matched using forward slashes, relative to the
+			// directory being walked
+			rel, err := filepath.Rel(name, p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+
+			if d.IsDir() {
+				// Don't walk excluded directories at all
+				if rel != "." && matchAny(exclude, rel) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			// Skip anything that isn't a regular file such as symbolic links or devices
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			if len(include) > 0 && !matchAny(include, rel) {
+				return nil
+			}
+
+			if matchAny(exclude, rel) || isBinary(p) {
+				return nil
+			}
+
+			w.files = append(w.files, p)
+			w.roots = append(w.roots, name)
+			return nil
+		})
+
+		if err != nil {
+			return walked{}, err
+		}
+	}
+
+	return w, nil
+}
+
+// validGlob checks every part of the glob is a valid pattern
+func validGlob(glob string) error {
+	for _, p := range strings.Split(glob, "/") {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidGlob, glob)
+		}
+	}
+
+	return nil
+}
+
+// matchAny checks if the slash separated relative path matches any of the globs.
+// A glob without a slash, such as *.go, is matched against the file name only,
+// otherwise it's matched against the whole relative path
+func matchAny(globs []string, rel string) bool {
+	for _, g := range globs {
+		target := rel
+		if !strings.Contains(g, "/") {
+			target = path.Base(rel)
+		}
+
+		if matchGlob(strings.Split(g, "/"), strings.Split(target, "/")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchGlob matches the path elements against the glob elements. Each element is
+// matched with path.Match, except "**" which matches zero or more elements, so
+// vendor/** matches the vendor directory and everything below it
+func matchGlob(glob, elems []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(elems); i++ {
+				if matchGlob(glob[1:], elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(elems) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(glob[0], elems[0]); !ok {
+			return false
+		}
+
+		glob, elems = glob[1:], elems[1:]
+	}
+
+	return len(elems) == 0
+}
+
+// isBinary checks if the file looks binary because it has a NUL byte near the
+// start. Compressed files are checked after decompressing them, since they can be
+// counted. If the file can't be read it's not considered binary, so the error is
+// reported when counting it
+func isBinary(fname string) bool {
+	f, err := os.Open(fname)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	r, err := decompress(f)
+	if err != nil {
+		return false
+	}
+
+	buf := make([]byte, binaryCheckSize)
+
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false
+	}
+
+	return bytes.IndexByte(buf[:n], 0) >= 0
+}
+
+// dirSubtotals adds up the counts of every file found walking a directory, for
+// the directory itself and each subdirectory, returning one row per directory
+// sorted by name. roots holds the directory each file was found walking, so the
+// files given by name are not added even if they are below a walked directory.
+// Files that failed to be counted are ignored
+func dirSubtotals(filenames, roots []string, results []counter.Counts, errs []error, cfg config) []row {
+	subtotals := map[string]*counter.Counts{}
+
+	for i, fname := range filenames {
+		if errs[i] != nil || roots[i] == "" {
+			continue
+		}
+
+		root := roots[i]
+
+		rel, err := filepath.Rel(root, fname)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		// Add the counts to every directory from the file up to the root
+		for d := filepath.Dir(rel); ; d = filepath.Dir(d) {
+			dir := filepath.Join(root, d)
+
+			if subtotals[dir] == nil {
+				subtotals[dir] = &counter.Counts{}
+			}
+			subtotals[dir].Add(results[i])
+
+			if d == "." {
+				break
+			}
+		}
+	}
+
+	names := make([]string, 0, len(subtotals))
+	for d := range subtotals {
+		names = append(names, d)
+	}
+	sort.Strings(names)
+
+	rows := make([]row, 0, len(names))
+	for _, d := range names {
+		// The trailing separator tells the directories apart from the files
+		rows = append(rows, row{name: d + string(filepath.Separator), values: values(*subtotals[d], cfg)})
+	}
+
+	return rows
+}
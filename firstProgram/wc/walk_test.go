@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// createTree creates a directory tree for the tests with the files and contents
+// given, returning the path of its root
+func createTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+
+	for name, content := range files {
+		fpath := filepath.Join(root, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(fpath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root
+}
+
+// TestWalkDirs tests walkDirs selects the files to count below a directory
+func TestWalkDirs(t *testing.T) {
+	root := createTree(t, map[string]string{
+		"main.go":                "package main\n",
+		"README.md":              "# Title\n",
+		"image.png":              "\x89PNG\x00\x00",
+		"cmd/tool/tool.go":       "package tool\n",
+		"cmd/tool/tool_test.go":  "package tool\n",
+		"vendor/lib/lib.go":      "package lib\n",
+		"internal/vendor/dep.go": "package dep\n",
+	})
+
+	testCases := []struct {
+		name    string
+		include []string
+		exclude []string
+		exp     []string
+	}{
+		{name: "NoFilter",
+			exp: []string{"README.md", "cmd/tool/tool.go", "cmd/tool/tool_test.go", "internal/vendor/dep.go",
+				"main.go", "vendor/lib/lib.go"},
+		},
+		{name: "Include", include: []string{"*.go"},
+			exp: []string{"cmd/tool/tool.go", "cmd/tool/tool_test.go", "internal/vendor/dep.go", "main.go",
+				"vendor/lib/lib.go"},
+		},
+		{name: "IncludeExclude", include: []string{"*.go"}, exclude: []string{"vendor/**", "*_test.go"},
+			exp: []string{"cmd/tool/tool.go", "internal/vendor/dep.go", "main.go"},
+		},
+		{name: "ExcludeAnyDepth", include: []string{"*.go"}, exclude: []string{"**/vendor/**"},
+			exp: []string{"cmd/tool/tool.go", "cmd/tool/tool_test.go", "main.go"},
+		},
+		{name: "IncludePath", include: []string{"cmd/**/*.go"},
+			exp: []string{"cmd/tool/tool.go", "cmd/tool/tool_test.go"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := walkDirs([]string{root, "missing.txt"}, tc.include, tc.exclude)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			exp := []string{}
+			for _, f := range tc.exp {
+				exp = append(exp, filepath.Join(root, filepath.FromSlash(f)))
+			}
+			// Names that are not directories are kept as they are
+			exp = append(exp, "missing.txt")
+
+			if !reflect.DeepEqual(exp, w.files) {
+				t.Errorf("Expected %q, got %q instead.\n", exp, w.files)
+			}
+
+			expRoots := []string{}
+			for range tc.exp {
+				expRoots = append(expRoots, root)
+			}
+			expRoots = append(expRoots, "")
+
+			if !reflect.DeepEqual(expRoots, w.roots) {
+				t.Errorf("Expected roots %q, got %q instead.\n", expRoots, w.roots)
+			}
+
+			if len(w.failed) != 0 {
+				t.Errorf("Expected no errors, got %v instead.\n", w.failed)
+			}
+		})
+	}
+
+	t.Run("InvalidGlob", func(t *testing.T) {
+		if _, err := walkDirs([]string{root}, []string{"[a-"}, nil); err == nil {
+			t.Error("Expected error, got nil instead.")
+		}
+	})
+}
+
+// TestRunRecursive tests the per file results and directory subtotals
+func TestRunRecursive(t *testing.T) {
+	root := createTree(t, map[string]string{
+		"a.txt":         "one two\n",
+		"sub/b.txt":     "three\nfour\n",
+		"sub/deep/c.go": "five six seven\n",
+		"bin.dat":       "\x00\x01\x02",
+	})
+
+	sep := string(filepath.Separator)
+
+	exp := "1 2 " + filepath.Join(root, "a.txt") + "\n" +
+		"2 2 " + filepath.Join(root, "sub", "b.txt") + "\n" +
+		"1 3 " + filepath.Join(root, "sub", "deep", "c.go") + "\n" +
+		"4 7 " + root + sep + "\n" +
+		"3 5 " + filepath.Join(root, "sub") + sep + "\n" +
+		"1 3 " + filepath.Join(root, "sub", "deep") + sep + "\n" +
+		"4 7 total\n"
+
+	cfg := config{format: "table", tokenizer: "whitespace", workers: 2, lines: true, words: true, recursive: true}
+
+	t.Run("Tree", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+
+		if err := run([]string{root}, cfg, nil, &out, &errOut); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.String() != exp {
+			t.Errorf("Expected %q, got %q instead.\n", exp, out.String())
+		}
+	})
+
+	// A file given by name is counted on its own, even below a walked directory,
+	// so it's not added to the subtotals twice
+	t.Run("FileInTree", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+
+		aFile := filepath.Join(root, "a.txt")
+		expFile := "1 2 " + aFile + "\n" +
+			"2 2 " + filepath.Join(root, "sub", "b.txt") + "\n" +
+			"1 3 " + filepath.Join(root, "sub", "deep", "c.go") + "\n" +
+			"1 2 " + aFile + "\n" +
+			"4 7 " + root + sep + "\n" +
+			"3 5 " + filepath.Join(root, "sub") + sep + "\n" +
+			"1 3 " + filepath.Join(root, "sub", "deep") + sep + "\n" +
+			"5 9 total\n"
+
+		if err := run([]string{root, aFile}, cfg, nil, &out, &errOut); err != nil {
+			t.Fatal(err)
+		}
+
+		if out.String() != expFile {
+			t.Errorf("Expected %q, got %q instead.\n", expFile, out.String())
+		}
+	})
+}
+
+// TestRunRecursiveUnreadable tests a directory that can't be read is reported
+// without stopping the count of the rest of the tree
+func TestRunRecursiveUnreadable(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("Permissions are not enforced for root")
+	}
+
+	root := createTree(t, map[string]string{
+		"a.txt":        "one two\n",
+		"locked/b.txt": "three\n",
+	})
+
+	locked := filepath.Join(root, "locked")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0755) })
+
+	var out, errOut bytes.Buffer
+
+	cfg := config{format: "table", tokenizer: "whitespace", workers: 2, lines: true, words: true, recursive: true}
+
+	err := run([]string{root}, cfg, nil, &out, &errOut)
+	if !errors.Is(err, ErrFilesFailed) {
+		t.Fatalf("Expected error %q, got %q instead.\n", ErrFilesFailed, err)
+	}
+
+	exp := "1 2 " + filepath.Join(root, "a.txt") + "\n" +
+		"1 2 " + root + string(filepath.Separator) + "\n" +
+		"1 2 total\n"
+
+	if out.String() != exp {
+		t.Errorf("Expected %q, got %q instead.\n", exp, out.String())
+	}
+
+	if !strings.Contains(errOut.String(), locked) {
+		t.Errorf("Expected error for %q, got %q instead.\n", locked, errOut.String())
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"pragprog.com/rggo/interacting/todo"
+)
+
+var (
+	// errConflict is returned when someone else changed an item while updating it
+	errConflict = errors.New("the list was changed by someone else, try again")
+	// errAPI is returned when the API replies with an unexpected status
+	errAPI = errors.New("API error")
+)
+
+// apiStore is a store keeping the list in a remote REST API served by the serve
+// subcommand, so several people can share the same list
+type apiStore struct {
+	root   string
+	client *http.Client
+}
+
+// newAPIStore returns a store using the API served at root, such as
+// http://localhost:8080. Requests taking longer than timeout fail
+func newAPIStore(root string, timeout time.Duration) *apiStore {
+	return &apiStore{
+		root:   strings.TrimSuffix(root, "/"),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Load gets every item from the API
+func (s *apiStore) Load() (todo.List, error) {
+	l, _, err := s.load()
+	return l, err
+}
+
+// Update gets the list from the API and changes it with fn, sending the whole new
+// list back in a single request. The API saves it only if nobody else changed the
+// list in the meantime, otherwise Update fails with errConflict. Either way every
+// change made by fn is saved or none of them
+func (s *apiStore) Update(fn func(l *todo.List) error) error {
+	l, tag, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	// Keep the encoding of the items before changing them, so the list is not sent
+	// when fn doesn't change it
+	before, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&l); err != nil {
+		return err
+	}
+
+	after, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(before, after) {
+		return nil
+	}
+
+	_, err = s.do(http.MethodPut, "/todo", l, tag, nil)
+
+	return err
+}
+
+// Close closes the connections kept open to send further requests
+func (s *apiStore) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// load gets every item from the API, with the ETag of the list
+func (s *apiStore) load() (todo.List, string, error) {
+	var res struct {
+		Results todo.List `json:"results"`
+	}
+
+	tag, err := s.do(http.MethodGet, "/todo", nil, "", &res)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if res.Results == nil {
+		res.Results = todo.List{}
+	}
+
+	return res.Results, tag, nil
+}
+
+// do sends a request to the API with body encoded as JSON, decoding the response
+// into res when it's not nil. When ifMatch is not empty it's sent in the If-Match
+// header. It returns the ETag of the response
+func (s *apiStore) do(method, path string, body any, ifMatch string, res any) (string, error) {
+	var r io.Reader
+
+	if body != nil {
+		js, err := json.Marshal(body)
+		if err != nil {
+			return "", err
+		}
+
+		r = bytes.NewReader(js)
+	}
+
+	req, err := http.NewRequest(method, s.root+path, r)
+	if err != nil {
+		return "", err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("cannot reach the API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return "", apiError(resp)
+	}
+
+	if res != nil {
+		if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+			return "", fmt.Errorf("%w: cannot decode the response: %s", errAPI, err)
+		}
+	}
+
+	return resp.Header.Get("ETag"), nil
+}
+
+// apiError returns the error matching a failed response, using the message in
+// the response body when there is one
+func apiError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+
+	msg := http.StatusText(resp.StatusCode)
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err == nil && body.Error != "" {
+		msg = body.Error
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %s", todo.ErrNotFound, msg)
+	case http.StatusPreconditionFailed:
+		return errConflict
+	default:
+		return fmt.Errorf("%w: %s: %s", errAPI, resp.Status, msg)
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pragprog.com/rggo/interacting/todo"
+)
+
+// setupClient starts a test server with two items kept in memory, returning a
+// client store using it and the store behind the server
+func setupClient(t *testing.T) (*apiStore, *todo.MemoryStore) {
+	t.Helper()
+
+	store := todo.NewMemoryStore()
+
+	err := store.Update(func(l *todo.List) error {
+		l.Add("Task 1")
+		l.Add("Task 2", todo.WithPriority(todo.PriorityHigh), todo.WithTags("work"))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(newMux(store, log.New(io.Discard, "", 0)))
+	t.Cleanup(ts.Close)
+
+	return newAPIStore(ts.URL+"/", time.Second), store
+}
+
+func TestClientLoad(t *testing.T) {
+	client, store := setupClient(t)
+
+	l, err := client.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The list is formatted the same as when it's kept locally
+	if l.String() != exp.String() {
+		t.Errorf("Expected %q, got %q instead", exp.String(), l.String())
+	}
+
+	if l[1].ID != exp[1].ID {
+		t.Errorf("Expected ID %q, got %q instead", exp[1].ID, l[1].ID)
+	}
+}
+
+func TestClientUpdate(t *testing.T) {
+	due := time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)
+
+	testCases := []struct {
+		name string
+		fn   func(l *todo.List) error
+		exp  string
+	}{
+		{name: "Add",
+			fn: func(l *todo.List) error {
+				l.Add("Task 3", todo.WithPriority(todo.PriorityLow), todo.WithDue(due))
+				return nil
+			},
+			exp: "  1: Task 1\n  2: Task 2 [high] #work\n  3: Task 3 [low] (due 2026-10-20)\n"},
+		{name: "Complete",
+			fn: func(l *todo.List) error {
+				return l.Complete(2)
+			},
+			exp: "  1: Task 1\nX 2: Task 2 [high] #work\n"},
+		{name: "Edit",
+			fn: func(l *todo.List) error {
+				return l.Edit(2, todo.WithPriority(todo.PriorityNone), todo.WithTags(), todo.WithDue(due))
+			},
+			exp: "  1: Task 1\n  2: Task 2 (due 2026-10-20)\n"},
+		{name: "Rename",
+			fn: func(l *todo.List) error {
+				return l.Edit(1, todo.WithTask("Task 1 renamed"))
+			},
+			exp: "  1: Task 1 renamed\n  2: Task 2 [high] #work\n"},
+		{name: "Delete",
+			fn: func(l *todo.List) error {
+				return l.Delete(1)
+			},
+			exp: "  1: Task 2 [high] #work\n"},
+		{name: "DeleteAndEdit",
+			fn: func(l *todo.List) error {
+				if err := l.Delete(1); err != nil {
+					return err
+				}
+				return l.Edit(1, todo.WithNotes("a note"))
+			},
+			exp: "  1: Task 2 [high] #work\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, store := setupClient(t)
+
+			if err := client.Update(tc.fn); err != nil {
+				t.Fatal(err)
+			}
+
+			l, err := store.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if l.String() != tc.exp {
+				t.Errorf("Expected %q, got %q instead", tc.exp, l.String())
+			}
+		})
+	}
+}
+
+func TestClientReopen(t *testing.T) {
+	client, store := setupClient(t)
+
+	for _, fn := range []func(l *todo.List) error{
+		func(l *todo.List) error { return l.Complete(1) },
+		func(l *todo.List) error { return l.Reopen(1) },
+	} {
+		if err := client.Update(fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l[0].Done || !l[0].CompletedAt.IsZero() {
+		t.Errorf("Expected item to be reopened, got %+v instead", l[0])
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	t.Run("NotFound", func(t *testing.T) {
+		client, _ := setupClient(t)
+
+		err := client.Update(func(l *todo.List) error {
+			_, err := l.Find("zzzz")
+			return err
+		})
+		if !errors.Is(err, todo.ErrNotFound) {
+			t.Errorf("Expected error %q, got %q instead", todo.ErrNotFound, err)
+		}
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		client, store := setupClient(t)
+
+		err := client.Update(func(l *todo.List) error {
+			// Someone else changes the item after it was loaded
+			err := store.Update(func(l *todo.List) error {
+				return l.Edit(2, todo.WithNotes("changed"))
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Deleting the first item doesn't conflict by itself, but it's not saved
+			// either, as every change is saved together
+			if err := l.Delete(1); err != nil {
+				return err
+			}
+
+			return l.Complete(1)
+		})
+		if !errors.Is(err, errConflict) {
+			t.Errorf("Expected error %q, got %q instead", errConflict, err)
+		}
+
+		l, err := store.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(l) != 2 || l[1].Done {
+			t.Errorf("Expected no changes to the list, got %q instead", l.String())
+		}
+	})
+
+	t.Run("ServerError", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			replyError(w, r, http.StatusInternalServerError, "disk full")
+		}))
+		defer ts.Close()
+
+		_, err := newAPIStore(ts.URL, time.Second).Load()
+		if !errors.Is(err, errAPI) || !strings.Contains(err.Error(), "disk full") {
+			t.Errorf("Expected error %q with the message, got %q instead", errAPI, err)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		done := make(chan struct{})
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-done
+		}))
+		defer ts.Close()
+		defer close(done)
+
+		_, err := newAPIStore(ts.URL, 50*time.Millisecond).Load()
+		if err == nil || !strings.Contains(err.Error(), "cannot reach the API") {
+			t.Errorf("Expected timeout error, got %q instead", err)
+		}
+	})
+}
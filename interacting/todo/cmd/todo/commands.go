@@ -0,0 +1,415 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"pragprog.com/rggo/interacting/todo"
+)
+
+// command is a subcommand of the tool, with its own flags and help text
+type command struct {
+	name string
+	// args describes the arguments of the command in its usage information
+	args    string
+	summary string
+	help    string
+	// minArgs and maxArgs are how many arguments the command accepts, maxArgs is
+	// -1 when there is no limit
+	minArgs int
+	maxArgs int
+	// local is true when the command always uses a local store, even when the
+	// TODO_API_ROOT Env Var is defined
+	local bool
+	// setFlags defines the flags of the command, besides -store
+	setFlags func(fs *flag.FlagSet)
+	// run executes the command with the arguments left after parsing the flags
+	run func(fs *flag.FlagSet, args []string, store todo.Store, in io.Reader, out io.Writer) error
+}
+
+// commands holds every subcommand in the order they are shown in the usage information
+var commands = []command{
+	{
+		name: "add", args: "[flags] [TASK...]", summary: "Add a task",
+		help:    "Adds a task to the list. Without arguments the task is read from STDIN.",
+		minArgs: 0, maxArgs: -1,
+		setFlags: itemFlags,
+		run:      runAdd,
+	},
+	{
+		name: "list", args: "[flags]", summary: "List the tasks",
+		help:    "Lists the tasks selected by the flags, all of them by default.",
+		minArgs: 0, maxArgs: 0,
+		setFlags: func(fs *flag.FlagSet) {
+			fs.String("priority", "", "List only tasks with this priority: none, low, medium or high")
+			fs.String("tags", "", "List only tasks with all these comma separated tags")
+			filterFlags(fs)
+		},
+		run: runList,
+	},
+	{
+		name: "done", args: "ITEM...", summary: "Mark tasks as completed",
+		help:    "Marks the items as completed.",
+		minArgs: 1, maxArgs: -1,
+		run: func(fs *flag.FlagSet, args []string, store todo.Store, in io.Reader, out io.Writer) error {
+			return updateItems(store, args, (*todo.List).Complete)
+		},
+	},
+	{
+		name: "undo", args: "ITEM...", summary: "Mark completed tasks as pending",
+		help:    "Marks completed items as pending again, clearing their completion time.",
+		minArgs: 1, maxArgs: -1,
+		run: func(fs *flag.FlagSet, args []string, store todo.Store, in io.Reader, out io.Writer) error {
+			return updateItems(store, args, (*todo.List).Reopen)
+		},
+	},
+	{
+		name: "rm", args: "ITEM...", summary: "Delete tasks",
+		help:    "Deletes the items. The items after them are renumbered, but keep their IDs.",
+		minArgs: 1, maxArgs: -1,
+		run: func(fs *flag.FlagSet, args []string, store todo.Store, in io.Reader, out io.Writer) error {
+			return updateItems(store, args, (*todo.List).Delete)
+		},
+	},
+	{
+		name: "edit", args: "[flags] ITEM [TASK...]", summary: "Change a task or its attributes",
+		help: "Changes the attributes of the item given by the flags, and its task when the new\n" +
+			"task follows the item. The other attributes are kept, and a flag set to an empty\n" +
+			"value removes the attribute.",
+		minArgs: 1, maxArgs: -1,
+		setFlags: itemFlags,
+		run:      runEdit,
+	},
+	{
+		name: "show", args: "ITEM", summary: "Show the details of a task",
+		help:    "Shows the item with its ID, times and notes.",
+		minArgs: 1, maxArgs: 1,
+		run: runShow,
+	},
+	{
+		name: "archive", args: "[flags]", summary: "Move completed tasks to an archive",
+		help: "Moves the completed tasks to an archive file, removing them from the list.\n" +
+			"The archive is a JSON file, the same as the list, so it can be listed with\n" +
+			"TODO_FILENAME=ARCHIVE todo list. The archive is always a local file, so -file\n" +
+			"is required when the list is kept by the API in TODO_API_ROOT.",
+		minArgs: 0, maxArgs: 0,
+		setFlags: func(fs *flag.FlagSet) {
+			fs.String("before", "", "Archive only tasks completed before this date (YYYY-MM-DD)")
+			fs.String("file", "", "Archive file (default the list file name ending in .archive.json)")
+		},
+		run: runArchive,
+	},
+	{
+		name: "serve", args: "[flags]", summary: "Serve the list over a REST API",
+		help: "Serves the list over a REST API until interrupted with Ctrl+C. The list is\n" +
+			"always kept in a local store, which other todo commands can use by setting\n" +
+			"TODO_API_ROOT to the address of the server.",
+		minArgs: 0, maxArgs: 0,
+		local: true,
+		setFlags: func(fs *flag.FlagSet) {
+			fs.String("addr", ":8080", "Address to listen on")
+		},
+		run: runServe,
+	},
+}
+
+// findCommand returns the command with the given name
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return command{}, false
+}
+
+// flagSet returns the flags of the command, printing errors and usage to w
+func (c command) flagSet(w io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("todo "+c.name, flag.ContinueOnError)
+	fs.SetOutput(w)
+
+	fs.Usage = func() {
+		fmt.Fprintf(w, "Usage: todo %s %s\n\n%s\n", c.name, c.args, c.help)
+
+		if strings.Contains(c.args, "ITEM") {
+			fmt.Fprintf(w, "Items are given by number, by ID or by a prefix of the ID matching a single item.\n")
+		}
+
+		fmt.Fprintf(w, "\nFlags:\n")
+		fs.PrintDefaults()
+	}
+
+	storeFlag(fs)
+
+	if c.setFlags != nil {
+		c.setFlags(fs)
+	}
+
+	return fs
+}
+
+// checkArgs checks the command was given the right number of arguments
+func (c command) checkArgs(args []string) error {
+	if len(args) < c.minArgs {
+		return fmt.Errorf("todo %s: missing arguments", c.name)
+	}
+
+	if c.maxArgs >= 0 && len(args) > c.maxArgs {
+		return fmt.Errorf("todo %s: too many arguments: %s", c.name, strings.Join(args[c.maxArgs:], " "))
+	}
+
+	return nil
+}
+
+// parseArgs parses the flags in args returning the remaining arguments. Unlike
+// FlagSet.Parse, flags can follow the arguments, as in todo edit 1 -priority high.
+// Arguments after -- are never parsed as flags
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	rest := []string{}
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		if fs.NArg() == 0 {
+			return rest, nil
+		}
+
+		// Parse stops at the first argument that is not a flag, or after --
+		if len(args) > fs.NArg() && args[len(args)-fs.NArg()-1] == "--" {
+			return append(rest, fs.Args()...), nil
+		}
+
+		rest = append(rest, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// flagValue returns the value of the flag name, or an empty string when the
+// command doesn't have the flag
+func flagValue(fs *flag.FlagSet, name string) string {
+	if f := fs.Lookup(name); f != nil {
+		return f.Value.String()
+	}
+
+	return ""
+}
+
+// storeFlag defines the -store flag used by every command
+func storeFlag(fs *flag.FlagSet) {
+	fs.String("store", "", "Where the list is kept: json, sqlite or memory (default json or $TODO_STORE)")
+}
+
+// itemFlags defines the flags setting the optional attributes of an item
+func itemFlags(fs *flag.FlagSet) {
+	fs.String("priority", "", "Priority of the task: none, low, medium or high")
+	fs.String("due", "", "Due date of the task as YYYY-MM-DD, empty to remove it")
+	fs.String("tags", "", "Comma separated tags of the task, empty to remove them")
+	fs.String("notes", "", "Notes about the task, can have several lines")
+}
+
+// filterFlags defines the flags selecting and sorting the items to list, besides
+// -priority and -tags which are defined with a different meaning by each command
+func filterFlags(fs *flag.FlagSet) {
+	fs.Bool("pending", false, "List only pending tasks")
+	fs.Bool("done", false, "List only done tasks")
+	fs.Bool("overdue", false, "List only pending tasks with a due date before today")
+	fs.String("created-after", "", "List only tasks created on or after this date (YYYY-MM-DD)")
+	fs.String("created-before", "", "List only tasks created before this date (YYYY-MM-DD)")
+	fs.String("completed-after", "", "List only tasks completed on or after this date (YYYY-MM-DD)")
+	fs.String("completed-before", "", "List only tasks completed before this date (YYYY-MM-DD)")
+	fs.String("sort", "", "Sort listed tasks by priority, due or created")
+	fs.Bool("verbose", false, "List tasks with their times and notes")
+}
+
+// runAdd adds the task given by the arguments, or read from in without arguments
+func runAdd(fs *flag.FlagSet, args []string, store todo.Store, in io.Reader, out io.Writer) error {
+	// When any arguments (excluding flags) are provided, they will be
+	// used as the new task
+	// ... suffix operator expands the slice into a list of values.
+	// The task is read before updating the list, so waiting for STDIN
+	// doesn't block other processes
+	t, err := getTask(in, args...)
+	if err != nil {
+		return err
+	}
+
+	opts, err := itemOptions(fs)
+	if err != nil {
+		return err
+	}
+
+	// Add the task and save the new list
+	return store.Update(func(l *todo.List) error {
+		l.Add(t, opts...)
+		return nil
+	})
+}
+
+// runList lists the items selected by the flags, keeping their numbers in the list
+func runList(fs *flag.FlagSet, args []string, store todo.Store, in io.Reader, out io.Writer) error {
+	f, err := listFilter(fs)
+	if err != nil {
+		return err
+	}
+
+	key, err := todo.ParseSortKey(flagValue(fs, "sort"))
+	if err != nil {
+		return err
+	}
+
+	// Read existing items from the store. Listing doesn't need a lock as every
+	// store saves the whole change at once
+	l, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	entries := l.Filter(f)
+	entries.Sort(key)
+
+	if flagValue(fs, "verbose") == "true" {
+		_, err = fmt.Fprint(out, entries.Verbose(time.Now()))
+		return err
+	}
+
+	// uses the fmt.Stringer String() interface implementation
+	_, err = fmt.Fprint(out, entries)
+	return err
+}
+
+// runEdit changes the attributes of the item given by the flags, and its task when
+// there are more arguments after the item
+func runEdit(fs *flag.FlagSet, args []string, store todo.Store, in io.Reader, out io.Writer) error {
+	opts, err := itemOptions(fs)
+	if err != nil {
+		return err
+	}
+
+	if len(args) > 1 {
+		t := strings.Join(args[1:], " ")
+		if strings.TrimSpace(t) == "" {
+			return fmt.Errorf("task cannot be blank")
+		}
+
+		opts = append(opts, todo.WithTask(t))
+	}
+
+	return updateItems(store, args[:1], func(l *todo.List, n int) error {
+		return l.Edit(n, opts...)
+	})
+}
+
+// runShow shows the item with its ID, times and notes
+func runShow(fs *flag.FlagSet, args []string, store todo.Store, in io.Reader, out io.Writer) error {
+	l, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	n, err := l.Find(args[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(out, l.Filter(todo.Filter{})[n-1:n].Verbose(time.Now()))
+	return err
+}
+
+// runArchive moves the completed items to the archive file
+func runArchive(fs *flag.FlagSet, args []string, store todo.Store, in io.Reader, out io.Writer) error {
+	before, err := parseDate(flagValue(fs, "before"))
+	if err != nil {
+		return err
+	}
+
+	// The list file name is known once the store is open. When the list is kept by
+	// the API there is no list file, and archiving to a local file named after it
+	// would be confusing, so the archive file must be given
+	file := flagValue(fs, "file")
+	if _, remote := store.(*apiStore); remote && file == "" {
+		return fmt.Errorf("archive needs the -file flag when the list is kept by the API in TODO_API_ROOT")
+	}
+
+	if file == "" {
+		file = strings.TrimSuffix(todoFileName, filepath.Ext(todoFileName)) + ".archive.json"
+	}
+
+	archived := todo.List{}
+
+	err = store.Update(func(l *todo.List) error {
+		entries := l.Filter(todo.Filter{Done: true, CompletedBefore: before})
+		if len(entries) == 0 {
+			return nil
+		}
+
+		for _, e := range entries {
+			archived = append(archived, (*l)[e.Number-1])
+		}
+
+		// The items are added to the archive before removing them from the list, so
+		// they are never lost, even if saving the list fails
+		err := todo.NewFileStore(file).Update(func(a *todo.List) error {
+			*a = append(*a, archived...)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Delete from the last item, so the numbers of the others don't change
+		for i := len(entries) - 1; i >= 0; i-- {
+			if err := l.Delete(entries[i].Number); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(out, "Archived %d tasks to %s\n", len(archived), file)
+	return err
+}
+
+// updateItems calls fn with the number of each item in refs and saves the list.
+// The items are looked up while updating the list, so they are still the same
+// items when changing them, and fn is called from the last item so deleting one
+// doesn't change the numbers of the others
+func updateItems(store todo.Store, refs []string, fn func(l *todo.List, n int) error) error {
+	return store.Update(func(l *todo.List) error {
+		numbers := []int{}
+		seen := map[int]bool{}
+
+		for _, ref := range refs {
+			n, err := l.Find(ref)
+			if err != nil {
+				return err
+			}
+
+			if !seen[n] {
+				seen[n] = true
+				numbers = append(numbers, n)
+			}
+		}
+
+		sort.Sort(sort.Reverse(sort.IntSlice(numbers)))
+
+		for _, n := range numbers {
+			if err := fn(l, n); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
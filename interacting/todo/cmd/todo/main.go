@@ -2,11 +2,14 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"pragprog.com/rggo/interacting/todo"
 )
@@ -14,98 +17,243 @@ import (
 // Hardcoding the file name
 var todoFileName = ".todo.json"
 
-// Command line flags:
-// -list: Boolean flag, when specified tool will list all to-do items
-// -task: String flag, when used tool will include string argument as new to do item in the list
-// -complete: Integer flag, when used tool will mark the item number as completed
+// defaultFileNames holds the file name used by each kind of store when the
+// TODO_FILENAME Env Var is not defined
+var defaultFileNames = map[string]string{
+	todo.StoreJSON:   ".todo.json",
+	todo.StoreSQLite: ".todo.db",
+}
+
+// errUsage is returned when the command line is not valid. The problem is reported
+// together with the usage information, so it's not printed again
+var errUsage = errors.New("invalid usage")
+
+// The tool is used with subcommands, each with its own flags:
+// todo add [flags] TASK...: adds a task, reading it from STDIN without arguments
+// todo list [flags]: lists the tasks, selected and sorted by the flags
+// todo done ITEM...: marks the items as completed
+// todo undo ITEM...: marks completed items as pending again
+// todo rm ITEM...: deletes the items
+// todo edit [flags] ITEM [TASK...]: changes the attributes of the item, or its task
+// todo show ITEM: shows the times and notes of the item
+// todo archive [flags]: moves the completed items to an archive file
+// todo serve [flags]: serves the list over a REST API, see runServe
+// Items are given by number, by ID or by a prefix of the ID matching a single item.
+// IDs don't change when other items are deleted, so they are safer for scripts.
+// The -add, -list, -complete and -edit flags of previous versions still work as
+// deprecated aliases of the add, list, done and edit subcommands
 func main() {
-	// Display custom usage message for tool.
-	// PrintDefaults will print usage information for each specified flag
-	flag.Usage = func() {
-		fmt.Fprintf(flag.CommandLine.Output(), "%s tool. Developed for the Pragmatic Bookshelf\n", os.Args[0])
-		fmt.Fprintf(flag.CommandLine.Output(), "Copyright 2020\n")
-		fmt.Fprintf(flag.CommandLine.Output(), "Usage information:")
-		flag.PrintDefaults()
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
 	}
+}
 
-	// Parsing command line flags
-	// Assigned variables are pointers, so will need to be dereferenced with * when used later
-	add := flag.Bool("add", false, "Add task to the ToDo list")
-	list := flag.Bool("list", false, "List all tasks")
-	complete := flag.Int("complete", 0, "Item to be completed")
+// run executes the subcommand given by the first argument, reading new tasks from
+// in and writing the output to out. Problems with the command line are reported
+// to errOut
+func run(args []string, in io.Reader, out, errOut io.Writer) error {
+	if len(args) == 0 {
+		usage(errOut)
+		return errUsage
+	}
 
-	flag.Parse()
+	// Arguments starting with a flag use the flags of previous versions
+	if strings.HasPrefix(args[0], "-") {
+		return runLegacy(args, in, out, errOut)
+	}
 
-	// Check if the user defined the Env Var for a custom file name
-	if os.Getenv("TODO_FILENAME") != "" {
-		todoFileName = os.Getenv("TODO_FILENAME")
+	if args[0] == "help" {
+		return help(args[1:], out, errOut)
 	}
 
-	// Create pointer to type todo.List by using address operator & to get the address
-	// of an empty instance of todo.List
-	l := &todo.List{}
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(errOut, "Unknown command %q\n\n", args[0])
+		usage(errOut)
+		return errUsage
+	}
 
-	// Read existing items from file
-	// Good practice to use STDERR for error messages rather than STDOUT so user can
-	// easily filter them out if they desire.
-	if err := l.Get(todoFileName); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	fs := cmd.flagSet(errOut)
+
+	cmdArgs, err := parseArgs(fs, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		// The flag package already reported the problem with the usage information
+		return errUsage
+	}
+
+	if err := cmd.checkArgs(cmdArgs); err != nil {
+		fmt.Fprintf(errOut, "%s\n\n", err)
+		fs.Usage()
+		return errUsage
+	}
+
+	open := openCLIStore
+	if cmd.local {
+		open = openStore
+	}
+
+	store, err := open(flagValue(fs, "store"))
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	return cmd.run(fs, cmdArgs, store, in, out)
+}
+
+// runLegacy executes the command given by the flags of previous versions, which
+// are deprecated aliases of the subcommands:
+// -add: Boolean flag, the same as todo add
+// -list: Boolean flag, the same as todo list
+// -complete: String flag, the same as todo done with a single item
+// -edit: String flag, the same as todo edit
+// The flags of the subcommands can be used with them, as in -list -pending
+func runLegacy(args []string, in io.Reader, out, errOut io.Writer) error {
+	fs := flag.NewFlagSet("todo", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+
+	// Display custom usage message for tool.
+	// PrintDefaults will print usage information for each specified flag
+	fs.Usage = func() {
+		usage(errOut)
+		fmt.Fprintln(errOut, "\nDeprecated flags:")
+		fs.PrintDefaults()
+	}
+
+	// Assigned variables are pointers, so will need to be dereferenced with * when used later
+	add := fs.Bool("add", false, "Add task to the ToDo list (deprecated, use todo add)")
+	list := fs.Bool("list", false, "List all tasks (deprecated, use todo list)")
+	complete := fs.String("complete", "", "Item to be completed (deprecated, use todo done)")
+	edit := fs.String("edit", "", "Item to be edited (deprecated, use todo edit)")
+	storeFlag(fs)
+	// -priority and -tags select the items to list when used with -list
+	itemFlags(fs)
+	filterFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return errUsage
 	}
 
 	// Decide what to do based on provided flags (need dereferencing with *)
+	var (
+		alias   string
+		cmd     string
+		cmdArgs []string
+	)
+
 	switch {
-	// Check if -list flag set
 	case *list:
-		// List current to do items
-		fmt.Print(l) // uses the fmt.Stringer String() interface implementation
-		// for _, item := range *l {
-		// print only items not marked as completed
-		// if !item.Done {
-		// 	fmt.Println(item.Task)
-		// }
-		// }
-
-	// Check if -complete flag set with value greater than 0 (default)
-	case *complete > 0:
-		// Complete the given item
-		if err := l.Complete(*complete); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
+		alias, cmd = "list", "list"
+	case *complete != "":
+		alias, cmd, cmdArgs = "complete", "done", []string{*complete}
+	case *edit != "":
+		alias, cmd, cmdArgs = "edit", "edit", []string{*edit}
+	case *add:
+		alias, cmd, cmdArgs = "add", "add", fs.Args()
+	default:
+		// Invalid flag provided
+		return errors.New("Invalid option")
+	}
 
-		// Save the new list
-		if err := l.Save(todoFileName); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
+	fmt.Fprintf(errOut, "Warning: the -%s flag is deprecated, use \"todo %s\" instead\n", alias, cmd)
 
-	// Add a new task if -add flag set
-	case *add:
-		// When any arguments (excluding flags) are provided, they will be
-		// used as the new task
-		// ... suffix operator expands the slice into a list of values
-		t, err := getTask(os.Stdin, flag.Args()...)
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
-		}
+	c, _ := findCommand(cmd)
+
+	store, err := openCLIStore(flagValue(fs, "store"))
+	if err != nil {
+		return err
+	}
+	defer store.Close()
 
-		// Add the task
-		l.Add(t)
+	return c.run(fs, cmdArgs, store, in, out)
+}
 
-		// Save the new list
-		if err := l.Save(todoFileName); err != nil {
-			fmt.Fprintln(os.Stderr, err)
-			os.Exit(1)
+// help prints the usage information of the tool, or of the command in args
+func help(args []string, out, errOut io.Writer) error {
+	if len(args) == 0 {
+		usage(out)
+		return nil
+	}
+
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(errOut, "Unknown command %q\n\n", args[0])
+		usage(errOut)
+		return errUsage
+	}
+
+	cmd.flagSet(out).Usage()
+
+	return nil
+}
+
+// usage prints the usage information of the tool, listing its commands
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "%s tool. Developed for the Pragmatic Bookshelf\n", filepath.Base(os.Args[0]))
+	fmt.Fprintf(w, "Copyright 2020\n")
+	fmt.Fprintf(w, "Usage: todo COMMAND [flags] [arguments]\n\nCommands:\n")
+
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+
+	fmt.Fprintf(w, "\nItems are given by number, by ID or by a prefix of the ID matching a single item.\n")
+	fmt.Fprintf(w, "Run \"todo help COMMAND\" or \"todo COMMAND -h\" for the flags of a command.\n")
+	fmt.Fprintf(w, "The -add, -list, -complete and -edit flags still work but are deprecated.\n")
+}
+
+// openCLIStore opens the store used by the commands. When the TODO_API_ROOT Env Var
+// is defined the list is kept by the REST API served at that address, such as
+// http://localhost:8080, so several people can share it. Requests time out after
+// the duration in the TODO_API_TIMEOUT Env Var, or 10 seconds when it's not defined.
+// Otherwise the store is opened with openStore
+func openCLIStore(kind string) (todo.Store, error) {
+	root := os.Getenv("TODO_API_ROOT")
+	if root == "" {
+		return openStore(kind)
+	}
+
+	timeout := 10 * time.Second
+
+	if v := os.Getenv("TODO_API_TIMEOUT"); v != "" {
+		var err error
+		if timeout, err = time.ParseDuration(v); err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid TODO_API_TIMEOUT %q: must be a positive duration such as 5s", v)
 		}
+	}
 
-	// Print error message to STDERR if an unhandled flag provided
-	default:
-		// Invalid flag provided
-		fmt.Fprintln(os.Stderr, "Invalid option")
-		os.Exit(1)
+	return newAPIStore(root, timeout), nil
+}
+
+// openStore opens the kind of store given by the -store flag, or by the TODO_STORE
+// Env Var when the flag is empty. The JSON file is used when neither is defined
+func openStore(kind string) (todo.Store, error) {
+	if kind == "" {
+		kind = os.Getenv("TODO_STORE")
+	}
+	if kind == "" {
+		kind = todo.StoreJSON
+	}
+
+	if name, ok := defaultFileNames[kind]; ok {
+		todoFileName = name
+	}
+
+	// Check if the user defined the Env Var for a custom file name
+	if os.Getenv("TODO_FILENAME") != "" {
+		todoFileName = os.Getenv("TODO_FILENAME")
 	}
+
+	return todo.OpenStore(kind, todoFileName)
 }
 
 // getTask function decides where to get the description for a new task from:
@@ -130,3 +278,107 @@ func getTask(r io.Reader, args ...string) (string, error) {
 
 	return s.Text(), nil
 }
+
+// itemOptions returns the options setting the item attributes given by the
+// -priority, -due, -tags and -notes flags. Only the flags used in the command line
+// are included, so editing an item doesn't change the other attributes, but a flag
+// set to an empty value removes the attribute
+func itemOptions(fs *flag.FlagSet) ([]todo.Option, error) {
+	opts := []todo.Option{}
+
+	var err error
+
+	// Visit only visits the flags that were set in the command line
+	fs.Visit(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+
+		value := f.Value.String()
+
+		switch f.Name {
+		case "priority":
+			var p todo.Priority
+			p, err = todo.ParsePriority(value)
+			opts = append(opts, todo.WithPriority(p))
+		case "due":
+			var due time.Time
+			due, err = parseDate(value)
+			opts = append(opts, todo.WithDue(due))
+		case "tags":
+			opts = append(opts, todo.WithTags(splitTags(value)...))
+		case "notes":
+			opts = append(opts, todo.WithNotes(value))
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return opts, nil
+}
+
+// listFilter returns the filter selecting the items to list, using the listing
+// flags set in the command line
+func listFilter(fs *flag.FlagSet) (todo.Filter, error) {
+	f := todo.Filter{}
+
+	var err error
+
+	fs.Visit(func(fl *flag.Flag) {
+		if err != nil {
+			return
+		}
+
+		value := fl.Value.String()
+
+		switch fl.Name {
+		case "pending":
+			f.Pending = value == "true"
+		case "done":
+			f.Done = value == "true"
+		case "overdue":
+			f.Overdue = value == "true"
+		case "priority":
+			var p todo.Priority
+			p, err = todo.ParsePriority(value)
+			f.Priority = &p
+		case "tags":
+			f.Tags = splitTags(value)
+		case "created-after":
+			f.CreatedAfter, err = parseDate(value)
+		case "created-before":
+			f.CreatedBefore, err = parseDate(value)
+		case "completed-after":
+			f.CompletedAfter, err = parseDate(value)
+		case "completed-before":
+			f.CompletedBefore, err = parseDate(value)
+		}
+	})
+
+	return f, err
+}
+
+// parseDate parses a date as YYYY-MM-DD in the local time zone. An empty value
+// returns the zero time
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// splitTags returns the comma separated tags in value, ignoring empty tags
+func splitTags(value string) []string {
+	tags := []string{}
+
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
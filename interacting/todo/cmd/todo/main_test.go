@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -68,7 +69,7 @@ func TestTodoCLI(t *testing.T) {
 
 	// Create first test to ensure tool can add a new task by using t.Run
 	t.Run("AddNewTaskFromArguments", func(t *testing.T) {
-		cmd := exec.Command(cmdPath, "-add", task)
+		cmd := exec.Command(cmdPath, "add", task)
 
 		if err := cmd.Run(); err != nil {
 			t.Fatal(err)
@@ -77,7 +78,7 @@ func TestTodoCLI(t *testing.T) {
 
 	task2 := "test task number 2"
 	t.Run("AddNewTaskFromSTDIN", func(t *testing.T) {
-		cmd := exec.Command(cmdPath, "-add")
+		cmd := exec.Command(cmdPath, "add")
 
 		// cmd.StdinPipe connects to STDIN pipe, so we can use io.WriteString to write contents
 		// of variable to STDIN. Need to ensure the pipe is closed with cmdStdIn.Close to ensure
@@ -96,7 +97,7 @@ func TestTodoCLI(t *testing.T) {
 
 	// Ensure the tool can list the tasks
 	t.Run("ListTasks", func(t *testing.T) {
-		cmd := exec.Command(cmdPath, "-list")
+		cmd := exec.Command(cmdPath, "list")
 		out, err := cmd.CombinedOutput()
 		if err != nil {
 			t.Fatal(err)
@@ -108,4 +109,437 @@ func TestTodoCLI(t *testing.T) {
 			t.Errorf("Expected %q, got %q instead\n", expected, string(out))
 		}
 	})
+
+	task3 := "test task number 3"
+	t.Run("AddNewTaskWithAttributes", func(t *testing.T) {
+		cmd := exec.Command(cmdPath, "add", "-priority", "high", "-due", "2026-10-20",
+			"-tags", "work, urgent", "-notes", "line 1\nline 2", task3)
+
+		if err := cmd.Run(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("EditTask", func(t *testing.T) {
+		cmd := exec.Command(cmdPath, "edit", "1", "-priority", "low")
+
+		if err := cmd.Run(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("ListTasksWithAttributes", func(t *testing.T) {
+		cmd := exec.Command(cmdPath, "list")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := fmt.Sprintf("  1: %s [low]\n  2: %s\n  3: %s [high] (due 2026-10-20) #work #urgent\n",
+			task, task2, task3)
+
+		if expected != string(out) {
+			t.Errorf("Expected %q, got %q instead\n", expected, string(out))
+		}
+	})
+
+	t.Run("ListFilteredSorted", func(t *testing.T) {
+		cmd := exec.Command(cmdPath, "list", "-pending", "-sort", "priority", "-tags", "work")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := fmt.Sprintf("  3: %s [high] (due 2026-10-20) #work #urgent\n", task3)
+
+		if expected != string(out) {
+			t.Errorf("Expected %q, got %q instead\n", expected, string(out))
+		}
+	})
+
+	t.Run("ListSortedByPriority", func(t *testing.T) {
+		cmd := exec.Command(cmdPath, "list", "-sort", "priority")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := fmt.Sprintf("  3: %s [high] (due 2026-10-20) #work #urgent\n  1: %s [low]\n  2: %s\n",
+			task3, task, task2)
+
+		if expected != string(out) {
+			t.Errorf("Expected %q, got %q instead\n", expected, string(out))
+		}
+	})
+
+	t.Run("ListWithoutPriority", func(t *testing.T) {
+		cmd := exec.Command(cmdPath, "list", "-priority", "none")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := fmt.Sprintf("  2: %s\n", task2)
+
+		if expected != string(out) {
+			t.Errorf("Expected %q, got %q instead\n", expected, string(out))
+		}
+	})
+
+	t.Run("ListVerbose", func(t *testing.T) {
+		cmd := exec.Command(cmdPath, "list", "-verbose", "-tags", "urgent")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{
+			fmt.Sprintf("  3: %s [high] (due 2026-10-20) #work #urgent\n", task3),
+			"     Created:   ",
+			"(just now)\n",
+			"     Due:       2026-10-20 (",
+			"     Notes:     line 1\n                line 2\n",
+		}
+
+		for _, exp := range expected {
+			if !strings.Contains(string(out), exp) {
+				t.Errorf("Expected %q in output, got %q instead\n", exp, string(out))
+			}
+		}
+	})
+
+	t.Run("AddInvalidPriority", func(t *testing.T) {
+		cmd := exec.Command(cmdPath, "add", "-priority", "urgent", "task")
+
+		if err := cmd.Run(); err == nil {
+			t.Errorf("Expected error adding task with invalid priority")
+		}
+	})
+
+	t.Run("CompleteByID", func(t *testing.T) {
+		out, err := exec.Command(cmdPath, "list", "-verbose", "-tags", "urgent").CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Find the ID of the task in the verbose listing
+		_, after, found := strings.Cut(string(out), "ID:")
+		if !found {
+			t.Fatalf("Expected ID in output, got %q instead\n", string(out))
+		}
+		id := strings.Fields(after)[0]
+
+		if err := exec.Command(cmdPath, "done", id).Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		out, err = exec.Command(cmdPath, "list", "-done").CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := fmt.Sprintf("X 3: %s [high] (due 2026-10-20) #work #urgent\n", task3)
+		if expected != string(out) {
+			t.Errorf("Expected %q, got %q instead\n", expected, string(out))
+		}
+	})
+
+	t.Run("CompleteUnknownID", func(t *testing.T) {
+		if err := exec.Command(cmdPath, "done", "abc").Run(); err == nil {
+			t.Errorf("Expected error completing an unknown item")
+		}
+	})
+
+	t.Run("AddConcurrently", func(t *testing.T) {
+		// Several processes adding tasks at the same time must not lose any of them
+		const n = 10
+
+		errs := make(chan error, n)
+
+		for i := 0; i < n; i++ {
+			cmd := exec.Command(cmdPath, "add", fmt.Sprintf("concurrent task %d", i))
+			go func() {
+				errs <- cmd.Run()
+			}()
+		}
+
+		for i := 0; i < n; i++ {
+			if err := <-errs; err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		out, err := exec.Command(cmdPath, "list").CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < n; i++ {
+			exp := fmt.Sprintf("concurrent task %d\n", i)
+			if !strings.Contains(string(out), exp) {
+				t.Errorf("Expected %q in output, got %q instead\n", exp, string(out))
+			}
+		}
+	})
+
+	t.Run("SQLiteStore", func(t *testing.T) {
+		dbFile := filepath.Join(t.TempDir(), "todo.db")
+		env := append(os.Environ(), "TODO_STORE=sqlite", "TODO_FILENAME="+dbFile)
+
+		cmd := exec.Command(cmdPath, "add", "-tags", "db", "sqlite task")
+		cmd.Env = env
+		if err := cmd.Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		cmd = exec.Command(cmdPath, "list")
+		cmd.Env = env
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "  1: sqlite task #db\n"
+		if expected != string(out) {
+			t.Errorf("Expected %q, got %q instead\n", expected, string(out))
+		}
+
+		// The -store flag takes precedence over the Env Var
+		cmd = exec.Command(cmdPath, "list", "-store", "memory")
+		cmd.Env = env
+		out, err = cmd.CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(out) != 0 {
+			t.Errorf("Expected empty list, got %q instead\n", string(out))
+		}
+	})
+
+	t.Run("InvalidStore", func(t *testing.T) {
+		cmd := exec.Command(cmdPath, "list", "-store", "csv")
+
+		if err := cmd.Run(); err == nil {
+			t.Errorf("Expected error using an invalid store")
+		}
+	})
+
+	t.Run("UndoTask", func(t *testing.T) {
+		if err := exec.Command(cmdPath, "undo", "3").Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := exec.Command(cmdPath, "list", "-done").CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(out) != 0 {
+			t.Errorf("Expected no done tasks, got %q instead\n", string(out))
+		}
+	})
+
+	t.Run("EditWithFlagsAfterItem", func(t *testing.T) {
+		if err := exec.Command(cmdPath, "edit", "2", "-notes", "a note").Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := exec.Command(cmdPath, "show", "2").CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{
+			fmt.Sprintf("  2: %s\n", task2),
+			"     ID:        ",
+			"     Notes:     a note\n",
+		}
+
+		for _, exp := range expected {
+			if !strings.Contains(string(out), exp) {
+				t.Errorf("Expected %q in output, got %q instead\n", exp, string(out))
+			}
+		}
+	})
+
+	t.Run("EditTaskText", func(t *testing.T) {
+		task2 = "test task number 2 renamed"
+
+		if err := exec.Command(cmdPath, "edit", "2", "test", "task", "number", "2", "renamed").Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := exec.Command(cmdPath, "show", "2").CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The other attributes are kept
+		expected := []string{
+			fmt.Sprintf("  2: %s\n", task2),
+			"     Notes:     a note\n",
+		}
+
+		for _, exp := range expected {
+			if !strings.Contains(string(out), exp) {
+				t.Errorf("Expected %q in output, got %q instead\n", exp, string(out))
+			}
+		}
+	})
+
+	t.Run("RemoveTasks", func(t *testing.T) {
+		// The 10 tasks added concurrently follow the first 3
+		if err := exec.Command(cmdPath, "rm", "4", "5", "13").Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := exec.Command(cmdPath, "list").CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if lines := strings.Count(string(out), "\n"); lines != 10 {
+			t.Errorf("Expected 10 tasks, got %d instead: %q\n", lines, string(out))
+		}
+
+		if !strings.HasPrefix(string(out), fmt.Sprintf("  1: %s [low]\n", task)) {
+			t.Errorf("Expected first tasks to be kept, got %q instead\n", string(out))
+		}
+	})
+
+	t.Run("ArchiveTasks", func(t *testing.T) {
+		archive := filepath.Join(t.TempDir(), "archive.json")
+
+		if err := exec.Command(cmdPath, "done", "1", "2").Run(); err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := exec.Command(cmdPath, "archive", "-file", archive).CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := fmt.Sprintf("Archived 2 tasks to %s\n", archive)
+		if expected != string(out) {
+			t.Errorf("Expected %q, got %q instead\n", expected, string(out))
+		}
+
+		out, err = exec.Command(cmdPath, "list", "-done").CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(out) != 0 {
+			t.Errorf("Expected no done tasks, got %q instead\n", string(out))
+		}
+
+		// The archive is a list file too
+		cmd := exec.Command(cmdPath, "list")
+		cmd.Env = append(os.Environ(), "TODO_FILENAME="+archive)
+		out, err = cmd.CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected = fmt.Sprintf("X 1: %s [low]\nX 2: %s\n", task, task2)
+		if expected != string(out) {
+			t.Errorf("Expected %q, got %q instead\n", expected, string(out))
+		}
+	})
+
+	t.Run("Help", func(t *testing.T) {
+		out, err := exec.Command(cmdPath, "help", "add").CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(string(out), "Usage: todo add [flags] [TASK...]\n") ||
+			!strings.Contains(string(out), "-priority") {
+			t.Errorf("Expected add usage, got %q instead\n", string(out))
+		}
+
+		if err := exec.Command(cmdPath, "add", "-h").Run(); err != nil {
+			t.Errorf("Expected no error asking for help, got %q", err)
+		}
+
+		out, err = exec.Command(cmdPath, "help", "serve").CombinedOutput()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(string(out), "Usage: todo serve [flags]\n") ||
+			!strings.Contains(string(out), "-addr") {
+			t.Errorf("Expected serve usage, got %q instead\n", string(out))
+		}
+	})
+
+	t.Run("ArchiveWithAPI", func(t *testing.T) {
+		// The API is not reached, as the missing -file flag is reported first
+		cmd := exec.Command(cmdPath, "archive")
+		cmd.Env = append(os.Environ(), "TODO_API_ROOT=http://127.0.0.1:1")
+
+		out, err := cmd.CombinedOutput()
+		if err == nil || !strings.Contains(string(out), "-file flag") {
+			t.Errorf("Expected error asking for -file, got %q instead\n", string(out))
+		}
+	})
+
+	t.Run("InvalidUsage", func(t *testing.T) {
+		for _, args := range [][]string{{}, {"unknown"}, {"done"}, {"show", "1", "2"}, {"list", "-unknown"},
+			{"serve", "-unknown"}, {"serve", "extra"}} {
+			if err := exec.Command(cmdPath, args...).Run(); err == nil {
+				t.Errorf("Expected error running todo %q", args)
+			}
+		}
+	})
+}
+
+// TestDeprecatedFlags tests the flags of previous versions still work, warning
+// they are deprecated
+func TestDeprecatedFlags(t *testing.T) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmdPath := filepath.Join(dir, binName)
+	env := append(os.Environ(), "TODO_FILENAME="+filepath.Join(t.TempDir(), "todo.json"))
+
+	testCases := []struct {
+		name    string
+		args    []string
+		expOut  string
+		warning string
+	}{
+		{name: "Add", args: []string{"-add", "task 1"}, warning: "-add"},
+		{name: "AddWithAttributes", args: []string{"-add", "-priority", "high", "task 2"}, warning: "-add"},
+		{name: "Complete", args: []string{"-complete", "1"}, warning: `-complete flag is deprecated, use "todo done"`},
+		{name: "Edit", args: []string{"-edit", "1", "-tags", "old"}, warning: "-edit"},
+		{name: "List", args: []string{"-list"}, expOut: "X 1: task 1 #old\n  2: task 2 [high]\n", warning: "-list"},
+		{name: "ListFiltered", args: []string{"-list", "-priority", "high"}, expOut: "  2: task 2 [high]\n", warning: "-list"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+
+			cmd := exec.Command(cmdPath, tc.args...)
+			cmd.Env = env
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+
+			if err := cmd.Run(); err != nil {
+				t.Fatal(err)
+			}
+
+			if stdout.String() != tc.expOut {
+				t.Errorf("Expected %q, got %q instead\n", tc.expOut, stdout.String())
+			}
+
+			if !strings.Contains(stderr.String(), tc.warning) || !strings.Contains(stderr.String(), "deprecated") {
+				t.Errorf("Expected deprecation warning for %q, got %q instead\n", tc.warning, stderr.String())
+			}
+		})
+	}
 }
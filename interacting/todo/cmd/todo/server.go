@@ -0,0 +1,506 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"pragprog.com/rggo/interacting/todo"
+)
+
+// errPrecondition is returned when the If-Match header of a request doesn't match
+// the current ETag, because someone else changed the list or the item in between
+var errPrecondition = errors.New("precondition failed: the list was changed, get it again")
+
+// errInvalidRequest is returned when the request body is not valid
+var errInvalidRequest = errors.New("invalid request")
+
+// errTooLarge is returned when the request body is larger than maxBodySize
+var errTooLarge = errors.New("request body too large")
+
+// maxBodySize is the largest request body accepted, so a client can't exhaust the
+// memory of the server. It's enough for a list of several thousand items
+const maxBodySize = 10 << 20
+
+// loggerKey is the key of the logger of failed requests in the request context
+type loggerKey struct{}
+
+// runServe runs the REST API server keeping the list in store, until the user
+// interrupts it with Ctrl+C. The -addr flag is the address to listen on. Failed
+// requests are logged to out
+func runServe(fs *flag.FlagSet, args []string, store todo.Store, in io.Reader, out io.Writer) error {
+	addr := flagValue(fs, "addr")
+
+	s := &http.Server{
+		Addr:         addr,
+		Handler:      newMux(store, log.New(out, "", log.LstdFlags)),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Stop accepting requests when interrupted, letting the current ones finish
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		s.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(out, "Serving the ToDo list on %s\n", addr)
+
+	if err := s.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// newMux returns the handler of the REST API, keeping the list in store:
+//
+//	GET    /todo      list every item
+//	POST   /todo      add an item
+//	PUT    /todo      replace every item at once
+//	GET    /todo/{id} get an item
+//	PATCH  /todo/{id} update the task or attributes of an item, or complete or reopen it
+//	DELETE /todo/{id} delete an item
+//
+// Items are given by number, ID or ID prefix, the same as in the command line.
+// Responses have an ETag header. Sending it back in the If-Match header of a
+// request changing the list makes it fail with 412 Precondition Failed if someone
+// else changed the list, or the item, in the meantime. PUT is used by clients
+// making several changes together, so either all of them are saved or none.
+// Failed requests are logged to logger
+func newMux(store todo.Store, logger *log.Logger) http.Handler {
+	m := http.NewServeMux()
+
+	m.HandleFunc("/todo", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			getAllHandler(w, r, store)
+		case http.MethodPost:
+			addHandler(w, r, store)
+		case http.MethodPut:
+			replaceHandler(w, r, store)
+		default:
+			w.Header().Set("Allow", "GET, POST, PUT")
+			replyError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	})
+
+	m.HandleFunc("/todo/", func(w http.ResponseWriter, r *http.Request) {
+		ref := strings.TrimPrefix(r.URL.Path, "/todo/")
+
+		switch r.Method {
+		case http.MethodGet:
+			getOneHandler(w, r, store, ref)
+		case http.MethodPatch:
+			patchHandler(w, r, store, ref)
+		case http.MethodDelete:
+			deleteHandler(w, r, store, ref)
+		default:
+			w.Header().Set("Allow", "GET, PATCH, DELETE")
+			replyError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	})
+
+	// The handlers reply errors with replyError, which finds the logger in the
+	// request context
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), loggerKey{}, logger)))
+	})
+}
+
+// todoResponse is the body of the response listing every item
+type todoResponse struct {
+	Results      todo.Entries `json:"results"`
+	TotalResults int          `json:"total_results"`
+}
+
+// addRequest is the body of the request adding an item. Only the task is required
+type addRequest struct {
+	Task     string
+	Priority todo.Priority
+	Due      time.Time
+	Tags     []string
+	Notes    string
+}
+
+// patchRequest is the body of the request updating an item. Only the attributes
+// given are changed. Setting Done to true completes the item and setting it to
+// false reopens it
+type patchRequest struct {
+	Task     *string
+	Done     *bool
+	Priority *todo.Priority
+	Due      *time.Time
+	Tags     *[]string
+	Notes    *string
+}
+
+// getAllHandler replies with every item in the list
+func getAllHandler(w http.ResponseWriter, r *http.Request, store todo.Store) {
+	l, err := store.Load()
+	if err != nil {
+		replyError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	entries := l.Filter(todo.Filter{})
+
+	replyJSON(w, r, http.StatusOK, etag(l), &todoResponse{
+		Results:      entries,
+		TotalResults: len(entries),
+	})
+}
+
+// getOneHandler replies with the item referenced by ref
+func getOneHandler(w http.ResponseWriter, r *http.Request, store todo.Store, ref string) {
+	l, err := store.Load()
+	if err != nil {
+		replyError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	e, err := findEntry(l, ref)
+	if err != nil {
+		replyStoreError(w, r, err)
+		return
+	}
+
+	replyJSON(w, r, http.StatusOK, etag(l[e.Number-1]), e)
+}
+
+// addHandler adds the item in the request body, replying with the new item
+func addHandler(w http.ResponseWriter, r *http.Request, store todo.Store) {
+	req := addRequest{}
+
+	if err := decodeBody(w, r, &req); err != nil {
+		replyStoreError(w, r, err)
+		return
+	}
+
+	if strings.TrimSpace(req.Task) == "" {
+		replyError(w, r, http.StatusBadRequest, "task cannot be blank")
+		return
+	}
+
+	var (
+		e   todo.Entry
+		tag string
+	)
+
+	// Adding an item changes the whole list, so If-Match is compared to its ETag
+	err := store.Update(func(l *todo.List) error {
+		if !matchETag(r, etag(*l)) {
+			return errPrecondition
+		}
+
+		l.Add(req.Task, todo.WithPriority(req.Priority), todo.WithDue(req.Due),
+			todo.WithTags(req.Tags...), todo.WithNotes(req.Notes))
+
+		e = l.Filter(todo.Filter{})[len(*l)-1]
+		tag = etag((*l)[len(*l)-1])
+
+		return nil
+	})
+	if err != nil {
+		replyStoreError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/todo/"+e.ID)
+	replyJSON(w, r, http.StatusCreated, tag, e)
+}
+
+// replaceHandler replaces every item with the list in the request body, replying
+// with the new list. Items keep their IDs, so they must all have a different one
+func replaceHandler(w http.ResponseWriter, r *http.Request, store todo.Store) {
+	req := todo.List{}
+
+	if err := decodeBody(w, r, &req); err != nil {
+		replyStoreError(w, r, err)
+		return
+	}
+
+	if err := checkList(req); err != nil {
+		replyError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var (
+		entries todo.Entries
+		tag     string
+	)
+
+	// Replacing the list conflicts with any change to it
+	err := store.Update(func(l *todo.List) error {
+		if !matchETag(r, etag(*l)) {
+			return errPrecondition
+		}
+
+		*l = req
+
+		entries = l.Filter(todo.Filter{})
+		tag = etag(*l)
+
+		return nil
+	})
+	if err != nil {
+		replyStoreError(w, r, err)
+		return
+	}
+
+	replyJSON(w, r, http.StatusOK, tag, &todoResponse{
+		Results:      entries,
+		TotalResults: len(entries),
+	})
+}
+
+// checkList checks every item in the list has a task and an ID different from the
+// other items
+func checkList(l todo.List) error {
+	ids := map[string]bool{}
+
+	for i, t := range l {
+		if strings.TrimSpace(t.Task) == "" {
+			return fmt.Errorf("%w: task of item %d cannot be blank", errInvalidRequest, i+1)
+		}
+
+		if t.ID == "" || ids[t.ID] {
+			return fmt.Errorf("%w: item %d needs a unique ID", errInvalidRequest, i+1)
+		}
+
+		ids[t.ID] = true
+	}
+
+	return nil
+}
+
+// patchHandler changes the item referenced by ref with the attributes in the
+// request body, replying with the changed item
+func patchHandler(w http.ResponseWriter, r *http.Request, store todo.Store, ref string) {
+	req := patchRequest{}
+
+	if err := decodeBody(w, r, &req); err != nil {
+		replyStoreError(w, r, err)
+		return
+	}
+
+	opts := []todo.Option{}
+
+	if req.Task != nil {
+		if strings.TrimSpace(*req.Task) == "" {
+			replyError(w, r, http.StatusBadRequest, "task cannot be blank")
+			return
+		}
+
+		opts = append(opts, todo.WithTask(*req.Task))
+	}
+	if req.Priority != nil {
+		opts = append(opts, todo.WithPriority(*req.Priority))
+	}
+	if req.Due != nil {
+		opts = append(opts, todo.WithDue(*req.Due))
+	}
+	if req.Tags != nil {
+		opts = append(opts, todo.WithTags(*req.Tags...))
+	}
+	if req.Notes != nil {
+		opts = append(opts, todo.WithNotes(*req.Notes))
+	}
+
+	var (
+		e   todo.Entry
+		tag string
+	)
+
+	err := store.Update(func(l *todo.List) error {
+		found, err := findEntry(*l, ref)
+		if err != nil {
+			return err
+		}
+
+		// Changing an item only conflicts with changes to the same item
+		if !matchETag(r, etag((*l)[found.Number-1])) {
+			return errPrecondition
+		}
+
+		if req.Done != nil && *req.Done != found.Done {
+			complete := l.Complete
+			if !*req.Done {
+				complete = l.Reopen
+			}
+
+			if err := complete(found.Number); err != nil {
+				return err
+			}
+		}
+
+		if err := l.Edit(found.Number, opts...); err != nil {
+			return err
+		}
+
+		e, err = findEntry(*l, found.ID)
+		tag = etag((*l)[found.Number-1])
+
+		return err
+	})
+	if err != nil {
+		replyStoreError(w, r, err)
+		return
+	}
+
+	replyJSON(w, r, http.StatusOK, tag, e)
+}
+
+// deleteHandler deletes the item referenced by ref
+func deleteHandler(w http.ResponseWriter, r *http.Request, store todo.Store, ref string) {
+	err := store.Update(func(l *todo.List) error {
+		n, err := l.Find(ref)
+		if err != nil {
+			return err
+		}
+
+		if !matchETag(r, etag((*l)[n-1])) {
+			return errPrecondition
+		}
+
+		return l.Delete(n)
+	})
+	if err != nil {
+		replyStoreError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findEntry returns the item referenced by ref with its number in the list
+func findEntry(l todo.List, ref string) (todo.Entry, error) {
+	n, err := l.Find(ref)
+	if err != nil {
+		return todo.Entry{}, err
+	}
+
+	return l.Filter(todo.Filter{})[n-1], nil
+}
+
+// decodeBody decodes the JSON request body into v, rejecting unknown attributes
+// so typos don't go unnoticed, and bodies larger than maxBodySize
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return fmt.Errorf("%w: limit is %d bytes", errTooLarge, maxErr.Limit)
+		}
+
+		return fmt.Errorf("%w: %s", errInvalidRequest, err)
+	}
+
+	return nil
+}
+
+// etag returns a strong ETag for v, which is either the whole list or one item,
+// using a hash of its JSON encoding so it changes whenever v changes
+func etag(v any) string {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(js)
+
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// matchETag checks if the If-Match header of the request, when given, matches
+// the current ETag. The header can have several ETags separated by commas, or *
+// to match anything
+func matchETag(r *http.Request, current string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+
+	return false
+}
+
+// replyJSON replies with v encoded as JSON and the given status, sending tag as
+// the ETag header. A GET request with a matching
+// If-None-Match header is replied with 304 Not Modified and no body
+func replyJSON(w http.ResponseWriter, r *http.Request, status int, tag string, v any) {
+	w.Header().Set("ETag", tag)
+
+	if r.Method == http.MethodGet && r.Header.Get("If-None-Match") == tag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	js, err := json.Marshal(v)
+	if err != nil {
+		replyError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(js)
+}
+
+// replyStoreError replies with the status matching an error returned while
+// reading the request, or finding or changing an item
+func replyStoreError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, todo.ErrNotFound):
+		replyError(w, r, http.StatusNotFound, err.Error())
+	case errors.Is(err, todo.ErrAmbiguousID), errors.Is(err, errInvalidRequest):
+		replyError(w, r, http.StatusBadRequest, err.Error())
+	case errors.Is(err, errPrecondition):
+		replyError(w, r, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, errTooLarge):
+		replyError(w, r, http.StatusRequestEntityTooLarge, err.Error())
+	default:
+		replyError(w, r, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// replyError replies with the error message as JSON, and logs it to the logger in
+// the request context, if any
+func replyError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if logger, ok := r.Context().Value(loggerKey{}).(*log.Logger); ok {
+		logger.Printf("%s %s: %d %s", r.Method, r.URL, status, message)
+	}
+
+	js, _ := json.Marshal(map[string]string{"error": message})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(js)
+}
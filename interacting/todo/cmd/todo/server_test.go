@@ -0,0 +1,489 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pragprog.com/rggo/interacting/todo"
+)
+
+// setupAPI starts a test server with two items kept in memory
+func setupAPI(t *testing.T) (string, func()) {
+	t.Helper()
+
+	store := todo.NewMemoryStore()
+
+	err := store.Update(func(l *todo.List) error {
+		l.Add("Task 1")
+		l.Add("Task 2", todo.WithPriority(todo.PriorityHigh), todo.WithTags("work"))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(newMux(store, log.New(io.Discard, "", 0)))
+
+	return ts.URL, ts.Close
+}
+
+// request sends a request with the given body and headers to the test server
+func request(t *testing.T, method, url, body string, headers map[string]string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp
+}
+
+// itemResponse holds the attributes of an item in a response checked by the tests
+type itemResponse struct {
+	Number   int
+	ID       string
+	Task     string
+	Done     bool
+	Priority string
+	Tags     []string
+	Notes    string
+}
+
+func TestGet(t *testing.T) {
+	testCases := []struct {
+		name       string
+		path       string
+		expCode    int
+		expItems   int
+		expContent string
+	}{
+		{name: "GetAll", path: "/todo", expCode: http.StatusOK, expItems: 2, expContent: `"Task":"Task 1"`},
+		{name: "GetOneByNumber", path: "/todo/2", expCode: http.StatusOK, expContent: `"Task":"Task 2"`},
+		{name: "GetOneNotFound", path: "/todo/3", expCode: http.StatusNotFound, expContent: `"error":`},
+		{name: "GetOneUnknownID", path: "/todo/zzzz", expCode: http.StatusNotFound, expContent: `"error":`},
+	}
+
+	url, cleanup := setupAPI(t)
+	defer cleanup()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := request(t, http.MethodGet, url+tc.path, "", nil)
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expCode {
+				t.Fatalf("Expected %q, got %q instead", http.StatusText(tc.expCode), http.StatusText(resp.StatusCode))
+			}
+
+			if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Expected content type application/json, got %q instead", ct)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(string(body), tc.expContent) {
+				t.Errorf("Expected %q in body, got %q instead", tc.expContent, string(body))
+			}
+
+			if tc.expItems == 0 {
+				return
+			}
+
+			var res struct {
+				Results      []itemResponse `json:"results"`
+				TotalResults int            `json:"total_results"`
+			}
+
+			if err := json.Unmarshal(body, &res); err != nil {
+				t.Fatal(err)
+			}
+
+			if res.TotalResults != tc.expItems || len(res.Results) != tc.expItems {
+				t.Errorf("Expected %d items, got %d instead", tc.expItems, res.TotalResults)
+			}
+
+			if res.Results[1].Number != 2 || res.Results[1].Priority != "high" || res.Results[1].ID == "" {
+				t.Errorf("Expected item 2 with its attributes, got %+v instead", res.Results[1])
+			}
+		})
+	}
+}
+
+func TestGetByID(t *testing.T) {
+	url, cleanup := setupAPI(t)
+	defer cleanup()
+
+	resp := request(t, http.MethodGet, url+"/todo/2", "", nil)
+	item := itemResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	for _, ref := range []string{item.ID, item.ID[:6]} {
+		resp := request(t, http.MethodGet, url+"/todo/"+ref, "", nil)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected %q getting %q, got %q instead", http.StatusText(http.StatusOK), ref, resp.Status)
+		}
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	url, cleanup := setupAPI(t)
+	defer cleanup()
+
+	resp := request(t, http.MethodGet, url+"/todo", "", nil)
+	resp.Body.Close()
+
+	tag := resp.Header.Get("ETag")
+	if tag == "" {
+		t.Fatal("Expected ETag header")
+	}
+
+	resp = request(t, http.MethodGet, url+"/todo", "", map[string]string{"If-None-Match": tag})
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("Expected %q, got %q instead", http.StatusText(http.StatusNotModified), resp.Status)
+	}
+}
+
+func TestAdd(t *testing.T) {
+	testCases := []struct {
+		name    string
+		body    string
+		expCode int
+	}{
+		{name: "Add", body: `{"Task":"Task 3","Priority":"low","Tags":["home"]}`, expCode: http.StatusCreated},
+		{name: "BlankTask", body: `{"Task":" "}`, expCode: http.StatusBadRequest},
+		{name: "InvalidPriority", body: `{"Task":"Task 3","Priority":"urgent"}`, expCode: http.StatusBadRequest},
+		{name: "UnknownAttribute", body: `{"Task":"Task 3","Color":"red"}`, expCode: http.StatusBadRequest},
+		{name: "InvalidJSON", body: `{"Task":`, expCode: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url, cleanup := setupAPI(t)
+			defer cleanup()
+
+			resp := request(t, http.MethodPost, url+"/todo", tc.body, nil)
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expCode {
+				t.Fatalf("Expected %q, got %q instead", http.StatusText(tc.expCode), resp.Status)
+			}
+
+			if tc.expCode != http.StatusCreated {
+				return
+			}
+
+			item := itemResponse{}
+			if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+				t.Fatal(err)
+			}
+
+			if item.Number != 3 || item.Task != "Task 3" || item.Priority != "low" {
+				t.Errorf("Expected new item 3, got %+v instead", item)
+			}
+
+			if loc := resp.Header.Get("Location"); loc != "/todo/"+item.ID {
+				t.Errorf("Expected location %q, got %q instead", "/todo/"+item.ID, loc)
+			}
+
+			// The new item can be read from its location
+			get := request(t, http.MethodGet, url+resp.Header.Get("Location"), "", nil)
+			get.Body.Close()
+
+			if get.StatusCode != http.StatusOK {
+				t.Errorf("Expected %q, got %q instead", http.StatusText(http.StatusOK), get.Status)
+			}
+		})
+	}
+}
+
+func TestPatch(t *testing.T) {
+	testCases := []struct {
+		name    string
+		path    string
+		body    string
+		expCode int
+		exp     itemResponse
+	}{
+		{name: "Complete", path: "/todo/1", body: `{"Done":true}`, expCode: http.StatusOK,
+			exp: itemResponse{Number: 1, Task: "Task 1", Done: true}},
+		{name: "Update", path: "/todo/2", body: `{"Priority":"low","Tags":[],"Notes":"a note"}`, expCode: http.StatusOK,
+			exp: itemResponse{Number: 2, Task: "Task 2", Priority: "low", Notes: "a note"}},
+		{name: "NotFound", path: "/todo/3", body: `{"Done":true}`, expCode: http.StatusNotFound},
+		{name: "Rename", path: "/todo/1", body: `{"Task":"Renamed task"}`, expCode: http.StatusOK,
+			exp: itemResponse{Number: 1, Task: "Renamed task"}},
+		{name: "BlankTask", path: "/todo/1", body: `{"Task":" "}`, expCode: http.StatusBadRequest},
+		{name: "InvalidBody", path: "/todo/1", body: `{"Color":"red"}`, expCode: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url, cleanup := setupAPI(t)
+			defer cleanup()
+
+			resp := request(t, http.MethodPatch, url+tc.path, tc.body, nil)
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expCode {
+				t.Fatalf("Expected %q, got %q instead", http.StatusText(tc.expCode), resp.Status)
+			}
+
+			if tc.expCode != http.StatusOK {
+				return
+			}
+
+			item := itemResponse{}
+			if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+				t.Fatal(err)
+			}
+
+			// IDs are random, so they are not compared
+			item.ID = ""
+
+			if item.Number != tc.exp.Number || item.Task != tc.exp.Task || item.Done != tc.exp.Done ||
+				item.Priority != tc.exp.Priority || len(item.Tags) != 0 || item.Notes != tc.exp.Notes {
+				t.Errorf("Expected %+v, got %+v instead", tc.exp, item)
+			}
+		})
+	}
+}
+
+func TestReplace(t *testing.T) {
+	testCases := []struct {
+		name    string
+		body    string
+		expCode int
+		expList string
+	}{
+		{name: "Replace", body: `[{"ID":"kkkk","Task":"Task A","Done":true},{"ID":"llll","Task":"Task B"}]`,
+			expCode: http.StatusOK, expList: "X 1: Task A\n  2: Task B\n"},
+		{name: "Empty", body: `[]`, expCode: http.StatusOK, expList: ""},
+		{name: "BlankTask", body: `[{"ID":"kkkk","Task":" "}]`, expCode: http.StatusBadRequest},
+		{name: "MissingID", body: `[{"Task":"Task A"}]`, expCode: http.StatusBadRequest},
+		{name: "DuplicateID", body: `[{"ID":"kkkk","Task":"Task A"},{"ID":"kkkk","Task":"Task B"}]`,
+			expCode: http.StatusBadRequest},
+		{name: "UnknownAttribute", body: `[{"ID":"kkkk","Task":"Task A","Color":"red"}]`,
+			expCode: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url, cleanup := setupAPI(t)
+			defer cleanup()
+
+			resp := request(t, http.MethodPut, url+"/todo", tc.body, nil)
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expCode {
+				t.Fatalf("Expected %q, got %q instead", http.StatusText(tc.expCode), resp.Status)
+			}
+
+			if tc.expCode != http.StatusOK {
+				return
+			}
+
+			var res struct {
+				Results todo.List `json:"results"`
+			}
+
+			if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+
+			if res.Results.String() != tc.expList {
+				t.Errorf("Expected %q, got %q instead", tc.expList, res.Results.String())
+			}
+		})
+	}
+}
+
+func TestReopen(t *testing.T) {
+	url, cleanup := setupAPI(t)
+	defer cleanup()
+
+	resp := request(t, http.MethodPatch, url+"/todo/1", `{"Done":true}`, nil)
+	resp.Body.Close()
+
+	resp = request(t, http.MethodPatch, url+"/todo/1", `{"Done":false}`, nil)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %q, got %q instead", http.StatusText(http.StatusOK), resp.Status)
+	}
+
+	item := itemResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		t.Fatal(err)
+	}
+
+	if item.Done {
+		t.Errorf("Expected item to be reopened, got %+v instead", item)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	url, cleanup := setupAPI(t)
+	defer cleanup()
+
+	resp := request(t, http.MethodDelete, url+"/todo/1", "", nil)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected %q, got %q instead", http.StatusText(http.StatusNoContent), resp.Status)
+	}
+
+	// The second item is now the first one
+	resp = request(t, http.MethodGet, url+"/todo/1", "", nil)
+	item := itemResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if item.Task != "Task 2" {
+		t.Errorf("Expected %q, got %q instead", "Task 2", item.Task)
+	}
+
+	resp = request(t, http.MethodDelete, url+"/todo/2", "", nil)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected %q, got %q instead", http.StatusText(http.StatusNotFound), resp.Status)
+	}
+}
+
+// TestOptimisticConcurrency tests that changes sent with an outdated ETag in the
+// If-Match header are rejected
+func TestOptimisticConcurrency(t *testing.T) {
+	url, cleanup := setupAPI(t)
+	defer cleanup()
+
+	getETag := func(path string) string {
+		resp := request(t, http.MethodGet, url+path, "", nil)
+		resp.Body.Close()
+
+		return resp.Header.Get("ETag")
+	}
+
+	listTag := getETag("/todo")
+	itemTag := getETag("/todo/1")
+	otherTag := getETag("/todo/2")
+
+	// Another client changes the first item
+	resp := request(t, http.MethodPatch, url+"/todo/1", `{"Notes":"changed"}`,
+		map[string]string{"If-Match": itemTag})
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %q, got %q instead", http.StatusText(http.StatusOK), resp.Status)
+	}
+
+	if resp.Header.Get("ETag") == itemTag {
+		t.Error("Expected the ETag to change with the item")
+	}
+
+	testCases := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		tag     string
+		expCode int
+	}{
+		{name: "PatchOutdated", method: http.MethodPatch, path: "/todo/1", body: `{"Done":true}`,
+			tag: itemTag, expCode: http.StatusPreconditionFailed},
+		{name: "DeleteOutdated", method: http.MethodDelete, path: "/todo/1",
+			tag: itemTag, expCode: http.StatusPreconditionFailed},
+		{name: "AddOutdated", method: http.MethodPost, path: "/todo", body: `{"Task":"Task 3"}`,
+			tag: listTag, expCode: http.StatusPreconditionFailed},
+		{name: "ReplaceOutdated", method: http.MethodPut, path: "/todo", body: `[]`,
+			tag: listTag, expCode: http.StatusPreconditionFailed},
+		{name: "PatchOtherItem", method: http.MethodPatch, path: "/todo/2", body: `{"Done":true}`,
+			tag: otherTag, expCode: http.StatusOK},
+		{name: "PatchAnyVersion", method: http.MethodPatch, path: "/todo/1", body: `{"Done":true}`,
+			tag: "*", expCode: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := request(t, tc.method, url+tc.path, tc.body, map[string]string{"If-Match": tc.tag})
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.expCode {
+				t.Errorf("Expected %q, got %q instead", http.StatusText(tc.expCode), resp.Status)
+			}
+		})
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	url, cleanup := setupAPI(t)
+	defer cleanup()
+
+	resp := request(t, http.MethodPut, url+"/todo/1", "", nil)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected %q, got %q instead", http.StatusText(http.StatusMethodNotAllowed), resp.Status)
+	}
+
+	if allow := resp.Header.Get("Allow"); allow != "GET, PATCH, DELETE" {
+		t.Errorf("Expected Allow header %q, got %q instead", "GET, PATCH, DELETE", allow)
+	}
+}
+
+// TestBodyTooLarge tests requests with a body larger than the limit are rejected
+func TestBodyTooLarge(t *testing.T) {
+	url, cleanup := setupAPI(t)
+	defer cleanup()
+
+	body := `{"Task":"` + strings.Repeat("a", maxBodySize) + `"}`
+
+	resp := request(t, http.MethodPost, url+"/todo", body, nil)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected %q, got %q instead", http.StatusText(http.StatusRequestEntityTooLarge), resp.Status)
+	}
+}
+
+// TestErrorLog tests failed requests are logged to the logger of the server
+func TestErrorLog(t *testing.T) {
+	var logOut bytes.Buffer
+
+	ts := httptest.NewServer(newMux(todo.NewMemoryStore(), log.New(&logOut, "", 0)))
+	defer ts.Close()
+
+	resp := request(t, http.MethodGet, ts.URL+"/todo/1", "", nil)
+	resp.Body.Close()
+
+	exp := "GET /todo/1: 404 not found: item 1\n"
+	if logOut.String() != exp {
+		t.Errorf("Expected log %q, got %q instead", exp, logOut.String())
+	}
+}
@@ -0,0 +1,192 @@
+package todo
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Filter holds the criteria to select items from a List. An item is selected when
+// it matches all the criteria that are set. The zero value selects every item
+type Filter struct {
+	// Pending selects the items not done yet
+	Pending bool
+	// Done selects the items already done
+	Done bool
+	// Tags selects the items having all these tags
+	Tags []string
+	// Priority selects the items with this priority when it's set. PriorityNone
+	// selects the items without a priority
+	Priority *Priority
+	// Overdue selects the pending items with a due date before today
+	Overdue bool
+	// CreatedAfter and CreatedBefore select the items created in this range. The
+	// start is inclusive and the end exclusive. A zero time doesn't limit the range
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// CompletedAfter and CompletedBefore select the done items completed in this range,
+	// the same way as the creation range
+	CompletedAfter  time.Time
+	CompletedBefore time.Time
+}
+
+// SortKey defines the order of the items returned by Entries.Sort
+type SortKey string
+
+const (
+	// SortNone keeps the items in the order they were added
+	SortNone SortKey = ""
+	// SortPriority sorts the items from the highest priority
+	SortPriority SortKey = "priority"
+	// SortDue sorts the items from the earliest due date, with items without a due
+	// date at the end
+	SortDue SortKey = "due"
+	// SortCreated sorts the items from the oldest
+	SortCreated SortKey = "created"
+)
+
+// ParseSortKey returns the SortKey with the given name. An empty name keeps the
+// order the items were added
+func ParseSortKey(name string) (SortKey, error) {
+	switch k := SortKey(name); k {
+	case SortNone, SortPriority, SortDue, SortCreated:
+		return k, nil
+	default:
+		return SortNone, fmt.Errorf("%w: %q", ErrInvalidSortKey, name)
+	}
+}
+
+// Entry is an item selected from a List together with its number in the list, so
+// it can still be completed or deleted after filtering and sorting.
+// The fields of the item are promoted, so they can be used directly as in e.Task
+type Entry struct {
+	Number int
+	item
+}
+
+// Entries represents a selection of items from a List
+type Entries []Entry
+
+// Filter returns the items of the list matching the filter, in the same order
+func (l *List) Filter(f Filter) Entries {
+	entries := Entries{}
+
+	// Items are overdue when their due date is before the start of today
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	for k, t := range *l {
+		if f.match(t, today) {
+			// Adjust the item number k to start from 1 instead of 0
+			entries = append(entries, Entry{Number: k + 1, item: t})
+		}
+	}
+
+	return entries
+}
+
+// match checks if the item matches all the criteria set in the filter
+func (f Filter) match(t item, today time.Time) bool {
+	if f.Pending && t.Done {
+		return false
+	}
+
+	if f.Done && !t.Done {
+		return false
+	}
+
+	for _, tag := range f.Tags {
+		if !t.hasTag(tag) {
+			return false
+		}
+	}
+
+	if f.Priority != nil && t.Priority != *f.Priority {
+		return false
+	}
+
+	if f.Overdue && (t.Done || t.Due == nil || !t.Due.Before(today)) {
+		return false
+	}
+
+	if !inRange(t.CreatedAt, f.CreatedAfter, f.CreatedBefore) {
+		return false
+	}
+
+	// Only done items have a completion time
+	if !f.CompletedAfter.IsZero() || !f.CompletedBefore.IsZero() {
+		if !t.Done || !inRange(t.CompletedAt, f.CompletedAfter, f.CompletedBefore) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasTag checks if the item has the given tag
+func (t item) hasTag(tag string) bool {
+	for _, v := range t.Tags {
+		if v == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inRange checks if t is in the range from start, inclusive, to end, exclusive.
+// A zero start or end doesn't limit that side of the range
+func inRange(t, start, end time.Time) bool {
+	if !start.IsZero() && t.Before(start) {
+		return false
+	}
+
+	if !end.IsZero() && !t.Before(end) {
+		return false
+	}
+
+	return true
+}
+
+// Sort sorts the entries by the given key. Entries with the same value keep their
+// relative order
+func (e Entries) Sort(key SortKey) {
+	var less func(a, b Entry) bool
+
+	switch key {
+	case SortPriority:
+		less = func(a, b Entry) bool { return a.Priority > b.Priority }
+	case SortDue:
+		less = func(a, b Entry) bool {
+			if a.Due == nil || b.Due == nil {
+				return a.Due != nil && b.Due == nil
+			}
+			return a.Due.Before(*b.Due)
+		}
+	case SortCreated:
+		less = func(a, b Entry) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	default:
+		return
+	}
+
+	sort.SliceStable(e, func(i, j int) bool {
+		return less(e[i], e[j])
+	})
+}
+
+// String formats the entries the same way as List.String, using the number of
+// each item in the list
+func (e Entries) String() string {
+	formatted := ""
+
+	for _, t := range e {
+		prefix := "  "
+		if t.Done {
+			prefix = "X "
+		}
+
+		formatted += fmt.Sprintf("%s%d: %s%s\n", prefix, t.Number, t.Task, t.attributes())
+	}
+
+	return formatted
+}
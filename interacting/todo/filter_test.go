@@ -0,0 +1,148 @@
+package todo_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"pragprog.com/rggo/interacting/todo"
+)
+
+// numbers returns the item numbers of the entries
+func numbers(e todo.Entries) []int {
+	n := []int{}
+	for _, v := range e {
+		n = append(n, v.Number)
+	}
+	return n
+}
+
+// testList returns a list with items covering every filter criteria
+func testList(t *testing.T) todo.List {
+	t.Helper()
+
+	day := func(d int) time.Time {
+		return time.Date(2026, 10, d, 12, 0, 0, 0, time.Local)
+	}
+	past := time.Now().AddDate(0, 0, -3)
+	future := time.Now().AddDate(0, 0, 3)
+
+	l := todo.List{}
+	l.Add("Task 1", todo.WithPriority(todo.PriorityLow), todo.WithTags("home"))
+	l.Add("Task 2", todo.WithPriority(todo.PriorityHigh), todo.WithTags("work", "urgent"), todo.WithDue(past))
+	l.Add("Task 3", todo.WithTags("work"), todo.WithDue(future))
+	l.Add("Task 4", todo.WithPriority(todo.PriorityHigh), todo.WithDue(past))
+
+	for i := range l {
+		l[i].CreatedAt = day(10 - i)
+	}
+
+	// Task 4 is done, so it's not overdue
+	if err := l.Complete(4); err != nil {
+		t.Fatal(err)
+	}
+	l[3].CompletedAt = day(12)
+
+	return l
+}
+
+// TestFilter tests the Filter method selects the items matching every criteria
+func TestFilter(t *testing.T) {
+	l := testList(t)
+
+	high, none := todo.PriorityHigh, todo.PriorityNone
+
+	testCases := []struct {
+		name   string
+		filter todo.Filter
+		exp    []int
+	}{
+		{name: "All", filter: todo.Filter{}, exp: []int{1, 2, 3, 4}},
+		{name: "Pending", filter: todo.Filter{Pending: true}, exp: []int{1, 2, 3}},
+		{name: "Done", filter: todo.Filter{Done: true}, exp: []int{4}},
+		{name: "Tag", filter: todo.Filter{Tags: []string{"work"}}, exp: []int{2, 3}},
+		{name: "Tags", filter: todo.Filter{Tags: []string{"work", "urgent"}}, exp: []int{2}},
+		{name: "Priority", filter: todo.Filter{Priority: &high}, exp: []int{2, 4}},
+		{name: "NoPriority", filter: todo.Filter{Priority: &none}, exp: []int{3}},
+		{name: "Overdue", filter: todo.Filter{Overdue: true}, exp: []int{2}},
+		{name: "PendingHigh", filter: todo.Filter{Pending: true, Priority: &high}, exp: []int{2}},
+		{name: "CreatedRange",
+			filter: todo.Filter{
+				CreatedAfter:  time.Date(2026, 10, 8, 0, 0, 0, 0, time.Local),
+				CreatedBefore: time.Date(2026, 10, 10, 0, 0, 0, 0, time.Local),
+			},
+			exp: []int{2, 3},
+		},
+		{name: "CompletedAfter",
+			filter: todo.Filter{CompletedAfter: time.Date(2026, 10, 11, 0, 0, 0, 0, time.Local)},
+			exp:    []int{4},
+		},
+		{name: "CompletedBefore",
+			filter: todo.Filter{CompletedBefore: time.Date(2026, 10, 11, 0, 0, 0, 0, time.Local)},
+			exp:    []int{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := numbers(l.Filter(tc.filter))
+
+			if !reflect.DeepEqual(tc.exp, res) {
+				t.Errorf("Expected %v, got %v instead.", tc.exp, res)
+			}
+		})
+	}
+}
+
+// TestSort tests sorting the entries keeps the item numbers
+func TestSort(t *testing.T) {
+	l := testList(t)
+
+	testCases := []struct {
+		name string
+		key  string
+		exp  []int
+	}{
+		{name: "None", key: "", exp: []int{1, 2, 3, 4}},
+		{name: "Priority", key: "priority", exp: []int{2, 4, 1, 3}},
+		{name: "Due", key: "due", exp: []int{2, 4, 3, 1}},
+		{name: "Created", key: "created", exp: []int{4, 3, 2, 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := todo.ParseSortKey(tc.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			e := l.Filter(todo.Filter{})
+			e.Sort(key)
+
+			if res := numbers(e); !reflect.DeepEqual(tc.exp, res) {
+				t.Errorf("Expected %v, got %v instead.", tc.exp, res)
+			}
+		})
+	}
+
+	if _, err := todo.ParseSortKey("name"); !errors.Is(err, todo.ErrInvalidSortKey) {
+		t.Errorf("Expected error %q, got %q instead.", todo.ErrInvalidSortKey, err)
+	}
+}
+
+// TestEntriesString tests the entries are formatted with their number in the list
+func TestEntriesString(t *testing.T) {
+	l := todo.List{}
+	l.Add("Task 1")
+	l.Add("Task 2", todo.WithPriority(todo.PriorityHigh))
+	l.Complete(1)
+
+	e := l.Filter(todo.Filter{})
+	e.Sort(todo.SortPriority)
+
+	exp := "  2: Task 2 [high]\nX 1: Task 1\n"
+	if e.String() != exp {
+		t.Errorf("Expected %q, got %q instead.", exp, e.String())
+	}
+}
@@ -0,0 +1,126 @@
+package todo
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// idLength is the number of bytes in an item ID, shown as twice as many letters
+const idLength = 6
+
+// idAlphabet holds the letters used to write each half byte of an ID. IDs only use
+// letters, as the change IDs in Jujutsu do, so they are never confused with item
+// numbers, not even when using a short prefix
+const idAlphabet = "klmnopqrstuvwxyz"
+
+// encodeID writes the bytes b as an ID, using one letter for every half byte
+func encodeID(b []byte) string {
+	var sb strings.Builder
+
+	for _, c := range b {
+		sb.WriteByte(idAlphabet[c>>4])
+		sb.WriteByte(idAlphabet[c&0x0f])
+	}
+
+	return sb.String()
+}
+
+// newID returns a new random ID, different from the IDs of the items in the list
+func (l *List) newID() string {
+	b := make([]byte, idLength)
+
+	for {
+		// crypto/rand doesn't fail on the supported operating systems
+		if _, err := rand.Read(b); err != nil {
+			panic(err)
+		}
+
+		if id := encodeID(b); !l.hasID(id) {
+			return id
+		}
+	}
+}
+
+// legacyID returns the ID of an item saved by a version without IDs. It's derived
+// from the creation time and the task, so the item keeps the same ID every time the
+// list is read until it's saved with it. n is increased to tell apart items with the
+// same task created at the same time
+func legacyID(t item, n int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d",
+		t.CreatedAt.UTC().Format(time.RFC3339Nano), t.Task, n)))
+
+	return encodeID(sum[:idLength])
+}
+
+// assignIDs migrates the items saved by a version without IDs, assigning them one
+func (l *List) assignIDs() {
+	ls := *l
+
+	for i := range ls {
+		if ls[i].ID != "" {
+			continue
+		}
+
+		id := legacyID(ls[i], 0)
+		for n := 1; l.hasID(id); n++ {
+			id = legacyID(ls[i], n)
+		}
+
+		ls[i].ID = id
+	}
+}
+
+// hasID checks if any item in the list has the given ID
+func (l *List) hasID(id string) bool {
+	for _, t := range *l {
+		if t.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Find returns the number of the item referenced by ref, which is either the item
+// number, its ID or a prefix of its ID long enough to match only that item.
+// Unlike numbers, IDs never change, so they are safer to use in scripts
+func (l *List) Find(ref string) (int, error) {
+	if ref == "" {
+		return 0, fmt.Errorf("%w: empty reference", ErrNotFound)
+	}
+
+	// IDs only have letters, so a reference with just digits is an item number
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n <= 0 || n > len(*l) {
+			return 0, fmt.Errorf("%w: item %d", ErrNotFound, n)
+		}
+
+		return n, nil
+	}
+
+	found := 0
+
+	for i, t := range *l {
+		if t.ID == ref {
+			return i + 1, nil
+		}
+
+		if strings.HasPrefix(t.ID, ref) {
+			if found != 0 {
+				return 0, fmt.Errorf("%w: %q", ErrAmbiguousID, ref)
+			}
+
+			found = i + 1
+		}
+	}
+
+	if found == 0 {
+		return 0, fmt.Errorf("%w: item %q", ErrNotFound, ref)
+	}
+
+	return found, nil
+}
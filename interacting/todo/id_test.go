@@ -0,0 +1,197 @@
+package todo_test
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pragprog.com/rggo/interacting/todo"
+)
+
+// TestAddID tests that new items get a unique ID made only of letters
+func TestAddID(t *testing.T) {
+	l := todo.List{}
+
+	for i := 0; i < 100; i++ {
+		l.Add("Task")
+	}
+
+	ids := map[string]bool{}
+
+	for _, item := range l {
+		if item.ID == "" || strings.Trim(item.ID, "abcdefghijklmnopqrstuvwxyz") != "" {
+			t.Errorf("Expected ID made of letters, got %q instead", item.ID)
+		}
+
+		if ids[item.ID] {
+			t.Errorf("Expected unique IDs, got %q twice", item.ID)
+		}
+		ids[item.ID] = true
+	}
+}
+
+// TestFind tests finding items by number, ID and ID prefix
+func TestFind(t *testing.T) {
+	l := todo.List{}
+	l.Add("Task 1")
+	l.Add("Task 2")
+	l.Add("Task 3")
+
+	// IDs are random, so use known ones
+	l[0].ID, l[1].ID, l[2].ID = "kkkkllll", "kkkkmmmm", "nnnnoooo"
+
+	testCases := []struct {
+		name   string
+		ref    string
+		exp    int
+		expErr error
+	}{
+		{name: "Number", ref: "2", exp: 2},
+		{name: "ID", ref: "kkkkmmmm", exp: 2},
+		{name: "Prefix", ref: "kkkkl", exp: 1},
+		{name: "ShortPrefix", ref: "n", exp: 3},
+		{name: "Ambiguous", ref: "kkkk", expErr: todo.ErrAmbiguousID},
+		{name: "UnknownID", ref: "zz", expErr: todo.ErrNotFound},
+		{name: "NumberOutOfRange", ref: "4", expErr: todo.ErrNotFound},
+		{name: "Zero", ref: "0", expErr: todo.ErrNotFound},
+		{name: "Empty", ref: "", expErr: todo.ErrNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := l.Find(tc.ref)
+
+			if tc.expErr != nil {
+				if !errors.Is(err, tc.expErr) {
+					t.Errorf("Expected error %q, got %q instead", tc.expErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %q instead", err)
+			}
+
+			if n != tc.exp {
+				t.Errorf("Expected item %d, got %d instead", tc.exp, n)
+			}
+		})
+	}
+}
+
+// TestFindAfterDelete tests that IDs keep referencing the same item when the
+// numbers change
+func TestFindAfterDelete(t *testing.T) {
+	l := todo.List{}
+	l.Add("Task 1")
+	l.Add("Task 2")
+	l.Add("Task 3")
+
+	id := l[2].ID
+
+	if err := l.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := l.Find(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l[n-1].Task != "Task 3" {
+		t.Errorf("Expected %q, got %q instead", "Task 3", l[n-1].Task)
+	}
+}
+
+// TestGetAssignsIDs tests that items saved without an ID get a unique one that
+// doesn't change until the list is saved with it
+func TestGetAssignsIDs(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "todo.json")
+
+	// Two items with the same task created at the same time still get different IDs
+	old := `[{"Task":"Old task","Done":false,"CreatedAt":"2026-10-01T10:00:00Z","CompletedAt":"0001-01-01T00:00:00Z"},` +
+		`{"Task":"Old task","Done":false,"CreatedAt":"2026-10-01T10:00:00Z","CompletedAt":"0001-01-01T00:00:00Z"}]`
+
+	if err := os.WriteFile(filename, []byte(old), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l1 := todo.List{}
+	if err := l1.Get(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	l2 := todo.List{}
+	if err := l2.Get(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	if l1[0].ID == "" || l1[0].ID == l1[1].ID {
+		t.Errorf("Expected unique IDs, got %q and %q instead", l1[0].ID, l1[1].ID)
+	}
+
+	if l1[0].ID != l2[0].ID || l1[1].ID != l2[1].ID {
+		t.Errorf("Expected the same IDs every time the list is read, got %q and %q instead", l1[0].ID, l2[0].ID)
+	}
+}
+
+// TestSQLiteStoreMigrateIDs tests that databases created without the id column
+// are migrated, assigning IDs to their items
+func TestSQLiteStoreMigrateIDs(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "todo.db")
+
+	// The driver is registered by the todo package
+	db, err := sql.Open("sqlite", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec(`CREATE TABLE items (
+		position INTEGER PRIMARY KEY, task TEXT NOT NULL, done INTEGER NOT NULL DEFAULT 0,
+		created_at TEXT NOT NULL DEFAULT '', completed_at TEXT NOT NULL DEFAULT '',
+		priority TEXT NOT NULL DEFAULT 'none', due TEXT NOT NULL DEFAULT '',
+		tags TEXT NOT NULL DEFAULT '[]', notes TEXT NOT NULL DEFAULT '');
+		INSERT INTO items (position, task, created_at) VALUES (1, 'Old task', '2026-10-01T10:00:00Z')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	s, err := todo.OpenSQLiteStore(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	l, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(l) != 1 || l[0].Task != "Old task" || l[0].ID == "" {
+		t.Fatalf("Expected old task with an ID, got %+v instead", l)
+	}
+
+	id := l[0].ID
+
+	// Updating the list saves the ID assigned when loading it
+	err = s.Update(func(l *todo.List) error {
+		l.Add("New task")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err = s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l[0].ID != id {
+		t.Errorf("Expected ID %q, got %q instead", id, l[0].ID)
+	}
+}
@@ -0,0 +1,143 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	// ErrLocked is returned when the list is still locked by another process
+	// after waiting for LockTimeout
+	ErrLocked = errors.New("list is locked by another process")
+
+	// LockTimeout is how long Lock waits for another process to release the lock
+	LockTimeout = 5 * time.Second
+
+	// LockStale is how old a lock must be to be considered abandoned, for example
+	// by a process that was killed before releasing it. Updating a list takes a few
+	// milliseconds, so any lock older than this is safe to break
+	LockStale = time.Minute
+)
+
+// lockRetry is how long Lock waits between attempts to acquire the lock
+const lockRetry = 10 * time.Millisecond
+
+// Lock acquires an exclusive advisory lock on the list saved in filename, so only
+// one process at a time can change it. Other processes calling Lock wait until the
+// lock is released, up to LockTimeout.
+// The lock is a separate file named after the list with the .lock suffix, created
+// exclusively so it works the same on every operating system. Call the returned
+// function to release the lock. It fails without removing the lock file if the lock
+// was broken as stale and taken by another process in the meantime
+func Lock(filename string) (func() error, error) {
+	lockName := filename + ".lock"
+	deadline := time.Now().Add(LockTimeout)
+
+	for {
+		// O_EXCL makes OpenFile fail if the file already exists, so only one process
+		// can create it
+		f, err := os.OpenFile(lockName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			// Write the process ID to help finding who holds the lock
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+
+			// Keep the info of the file after writing it to tell it apart from a lock
+			// taken by another process when releasing it
+			owned, err := f.Stat()
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(lockName)
+				return nil, err
+			}
+
+			return func() error {
+				if !removeLock(lockName, owned) {
+					return fmt.Errorf("lock %s was broken by another process", lockName)
+				}
+				return nil
+			}, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		// Break a lock abandoned by a process that didn't release it
+		if info, err := os.Stat(lockName); err == nil && time.Since(info.ModTime()) > LockStale {
+			removeLock(lockName, info)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: %s", ErrLocked, lockName)
+		}
+
+		time.Sleep(lockRetry)
+	}
+}
+
+// removeLock removes the lock file lockName if it's still the file with the given
+// info, either the lock taken by this process or one found abandoned, reporting if
+// it was removed. Other processes may find the same stale lock and break it at
+// once, and one of them may take a new lock right after, so simply removing the
+// file could remove that new lock instead. The file is renamed first, which only
+// one process can do, and put back if it turns out to be a different file
+func removeLock(lockName string, info os.FileInfo) bool {
+	f, err := os.CreateTemp(filepath.Dir(lockName), filepath.Base(lockName)+".stale-*")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	// Rename replaces the temporary file. It fails if another process already
+	// broke the lock and nobody took a new one
+	if err := os.Rename(lockName, f.Name()); err != nil {
+		return false
+	}
+
+	// A new lock file could reuse the same inode, but not the old time
+	renamed, err := os.Stat(f.Name())
+	if err == nil && os.SameFile(renamed, info) && renamed.ModTime().Equal(info.ModTime()) {
+		return true
+	}
+
+	// Link fails instead of replacing the lock taken by yet another process
+	os.Link(f.Name(), lockName)
+	return false
+}
+
+// Update safely changes the list saved in filename while other processes may be
+// changing it too. It locks the list, reads it, calls fn to change it and saves it
+// before releasing the lock, so no changes are lost. If fn returns an error the
+// list is not saved
+func Update(filename string, fn func(l *List) error) (err error) {
+	unlock, err := Lock(filename)
+	if err != nil {
+		return err
+	}
+
+	// Always release the lock, reporting the error only if everything else worked
+	defer func() {
+		if uerr := unlock(); err == nil {
+			err = uerr
+		}
+	}()
+
+	l := &List{}
+
+	if err := l.Get(filename); err != nil {
+		return err
+	}
+
+	if err := fn(l); err != nil {
+		return err
+	}
+
+	return l.Save(filename)
+}
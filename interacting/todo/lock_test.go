@@ -0,0 +1,222 @@
+package todo_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"pragprog.com/rggo/interacting/todo"
+)
+
+// TestUpdateConcurrent tests that concurrent updates don't lose any changes
+func TestUpdateConcurrent(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "todo.json")
+
+	const n = 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			errs <- todo.Update(filename, func(l *todo.List) error {
+				l.Add(fmt.Sprintf("Task %d", i))
+				return nil
+			})
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l := todo.List{}
+	if err := l.Get(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(l) != n {
+		t.Errorf("Expected %d tasks, got %d instead", n, len(l))
+	}
+
+	if _, err := os.Stat(filename + ".lock"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected lock file to be removed, got %v", err)
+	}
+}
+
+// TestUpdateError tests that the list is not saved when the update fails
+func TestUpdateError(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "todo.json")
+
+	err := todo.Update(filename, func(l *todo.List) error {
+		l.Add("Task")
+		return l.Complete(2)
+	})
+	if err == nil {
+		t.Fatal("Expected error, got nil instead")
+	}
+
+	if _, err := os.Stat(filename); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected list not to be saved, got %v", err)
+	}
+
+	if _, err := os.Stat(filename + ".lock"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected lock file to be removed, got %v", err)
+	}
+}
+
+// TestLock tests waiting for a lock and breaking a stale one
+func TestLock(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "todo.json")
+
+	defer func(timeout time.Duration) { todo.LockTimeout = timeout }(todo.LockTimeout)
+	todo.LockTimeout = 50 * time.Millisecond
+
+	unlock, err := todo.Lock(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Locked", func(t *testing.T) {
+		if _, err := todo.Lock(filename); !errors.Is(err, todo.ErrLocked) {
+			t.Errorf("Expected error %q, got %q instead", todo.ErrLocked, err)
+		}
+	})
+
+	t.Run("Stale", func(t *testing.T) {
+		old := time.Now().Add(-2 * todo.LockStale)
+		if err := os.Chtimes(filename+".lock", old, old); err != nil {
+			t.Fatal(err)
+		}
+
+		newUnlock, err := todo.Lock(filename)
+		if err != nil {
+			t.Fatalf("Expected stale lock to be broken, got %q", err)
+		}
+
+		// The first lock was broken, so releasing it fails and keeps the new lock
+		if err := unlock(); err == nil {
+			t.Error("Expected error releasing a broken lock")
+		}
+
+		if _, err := os.Stat(filename + ".lock"); err != nil {
+			t.Errorf("Expected the new lock to be kept, got %q", err)
+		}
+
+		if err := newUnlock(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// The lock is released, so releasing it again fails
+	if err := unlock(); err == nil {
+		t.Error("Expected error releasing a released lock")
+	}
+}
+
+// TestLockStaleConcurrent tests that processes breaking the same stale lock at
+// once never hold the new lock at the same time
+func TestLockStaleConcurrent(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "todo.json")
+	old := time.Now().Add(-2 * todo.LockStale)
+
+	for round := 0; round < 5; round++ {
+		if err := os.WriteFile(filename+".lock", []byte("0\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chtimes(filename+".lock", old, old); err != nil {
+			t.Fatal(err)
+		}
+
+		var (
+			wg      sync.WaitGroup
+			mu      sync.Mutex
+			holders int
+			maxHeld int
+		)
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				unlock, err := todo.Lock(filename)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				mu.Lock()
+				holders++
+				if holders > maxHeld {
+					maxHeld = holders
+				}
+				mu.Unlock()
+
+				time.Sleep(time.Millisecond)
+
+				mu.Lock()
+				holders--
+				mu.Unlock()
+
+				if err := unlock(); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		if maxHeld > 1 {
+			t.Fatalf("Expected a single lock holder, got %d at once", maxHeld)
+		}
+	}
+}
+
+// TestSaveAtomic tests that saving leaves no temporary files behind
+func TestSaveAtomic(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "todo.json")
+
+	l := todo.List{}
+	l.Add("Task")
+
+	for i := 0; i < 2; i++ {
+		if err := l.Save(filename); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 || files[0].Name() != "todo.json" {
+		t.Errorf("Expected only todo.json, got %v instead", files)
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("Expected mode 0644, got %v instead", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,347 @@
+package todo
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	// Pure Go SQLite driver, so the tool doesn't need cgo or the SQLite library
+	_ "modernc.org/sqlite"
+)
+
+// schema creates the table holding the items in the SQLite database. The position
+// keeps the items in the same order as the list, so they keep their numbers
+const schema = `CREATE TABLE IF NOT EXISTS items (
+	position     INTEGER PRIMARY KEY,
+	id           TEXT NOT NULL DEFAULT '',
+	task         TEXT NOT NULL,
+	done         INTEGER NOT NULL DEFAULT 0,
+	created_at   TEXT NOT NULL DEFAULT '',
+	completed_at TEXT NOT NULL DEFAULT '',
+	priority     TEXT NOT NULL DEFAULT 'none',
+	due          TEXT NOT NULL DEFAULT '',
+	tags         TEXT NOT NULL DEFAULT '[]',
+	notes        TEXT NOT NULL DEFAULT ''
+)`
+
+// SQLiteStore keeps the list in a table of an SQLite database file, which suits
+// large lists shared by a team better than a JSON file and can be queried with
+// any SQLite tool
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLiteStore opens the SQLite database in filename, creating it if it doesn't
+// exist yet
+func OpenSQLiteStore(filename string) (*SQLiteStore, error) {
+	// Wait for other processes updating the list instead of failing straight away,
+	// and take the write lock when starting a transaction so two updates can't read
+	// the same list and then overwrite each other's changes
+	q := url.Values{}
+	q.Add("_pragma", "busy_timeout(5000)")
+	q.Add("_txlock", "immediate")
+
+	// The path is escaped in the URI, so a ? or # in the name doesn't start the
+	// parameters
+	dsn := url.URL{Scheme: "file", Path: filepath.ToSlash(filename), RawQuery: q.Encode()}
+
+	db, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// migrate adds the columns missing from databases created by previous versions.
+// The items without an ID get one when loading them
+func migrate(db *sql.DB) error {
+	rows, err := db.Query("SELECT name FROM pragma_table_info('items')")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns := map[string]bool{}
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+
+		columns[name] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if !columns["id"] {
+		if _, err := db.Exec("ALTER TABLE items ADD COLUMN id TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Load reads the list from the database
+func (s *SQLiteStore) Load() (List, error) {
+	return load(s.db)
+}
+
+// Update changes the list in a single transaction, so other processes either see
+// the whole change or none of it. Only the rows of the items added, changed, moved
+// or deleted by fn are written, so updating a large list stays fast
+func (s *SQLiteStore) Update(fn func(l *List) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Rollback does nothing once the transaction is committed
+	defer tx.Rollback()
+
+	l, err := load(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := saveIDs(tx, l); err != nil {
+		return err
+	}
+
+	// Keep the rows before changing the items, indexed by ID, with their positions.
+	// Options replace the tags of an item rather than changing them, so the rows
+	// can't change with the items
+	type oldRow struct {
+		position int
+		values   []any
+	}
+
+	old := map[string]oldRow{}
+
+	for i, t := range l {
+		values, err := rowValues(t)
+		if err != nil {
+			return err
+		}
+
+		old[t.ID] = oldRow{position: i + 1, values: values}
+	}
+
+	if err := fn(&l); err != nil {
+		return err
+	}
+
+	kept := map[string]bool{}
+	for _, t := range l {
+		kept[t.ID] = true
+	}
+
+	for id := range old {
+		if !kept[id] {
+			if _, err := tx.Exec("DELETE FROM items WHERE id = ?", id); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Deleting an item renumbers the items after it, so the rows being moved are
+	// first set aside with a negative position, which no other row uses, to avoid
+	// clashing with the rows still in the positions they are moving to
+	for i, t := range l {
+		if o, ok := old[t.ID]; ok && o.position != i+1 {
+			if _, err := tx.Exec("UPDATE items SET position = -position WHERE id = ?", t.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, t := range l {
+		values, err := rowValues(t)
+		if err != nil {
+			return err
+		}
+
+		o, ok := old[t.ID]
+
+		switch {
+		case !ok:
+			_, err = tx.Exec(`INSERT INTO items
+				(id, task, done, created_at, completed_at, priority, due, tags, notes, position)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				append(values, i+1)...)
+		case o.position != i+1 || !reflect.DeepEqual(o.values, values):
+			_, err = tx.Exec(`UPDATE items SET
+				id = ?, task = ?, done = ?, created_at = ?, completed_at = ?, priority = ?, due = ?,
+				tags = ?, notes = ?, position = ?
+				WHERE id = ?`,
+				append(values, i+1, t.ID)...)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// saveIDs saves the IDs assigned when loading the items saved without one, so their
+// rows can be found by ID. l must be the list just loaded, in the order of the rows
+func saveIDs(tx *sql.Tx, l List) error {
+	rows, err := tx.Query("SELECT position, id FROM items ORDER BY position")
+	if err != nil {
+		return err
+	}
+
+	// Positions of the rows without an ID, by the ID assigned to their item
+	missing := map[string]int{}
+
+	for i := 0; rows.Next(); i++ {
+		var (
+			position int
+			id       string
+		)
+
+		if err := rows.Scan(&position, &id); err != nil {
+			rows.Close()
+			return err
+		}
+
+		if id == "" && i < len(l) {
+			missing[l[i].ID] = position
+		}
+	}
+
+	// The rows must be closed before changing the table
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, position := range missing {
+		if _, err := tx.Exec("UPDATE items SET id = ? WHERE position = ?", id, position); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rowValues returns the values of the columns saving the item t, besides its
+// position, in the order of the schema
+func rowValues(t item) ([]any, error) {
+	tags, err := json.Marshal(t.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	due := ""
+	if t.Due != nil {
+		due = formatTime(*t.Due)
+	}
+
+	return []any{t.ID, t.Task, t.Done, formatTime(t.CreatedAt), formatTime(t.CompletedAt),
+		t.Priority.String(), due, string(tags), t.Notes}, nil
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// querier is implemented by both sql.DB and sql.Tx, so the list can be loaded
+// inside or outside a transaction
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// load reads every item in the database, in the order of the list
+func load(q querier) (List, error) {
+	rows, err := q.Query(`SELECT id, task, done, created_at, completed_at, priority, due, tags, notes
+		FROM items ORDER BY position`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	l := List{}
+
+	for rows.Next() {
+		var (
+			t                       item
+			created, completed, due string
+			priority, tags          string
+		)
+
+		err := rows.Scan(&t.ID, &t.Task, &t.Done, &created, &completed, &priority, &due, &tags, &t.Notes)
+		if err != nil {
+			return nil, err
+		}
+
+		if t.CreatedAt, err = parseTime(created); err != nil {
+			return nil, err
+		}
+		if t.CompletedAt, err = parseTime(completed); err != nil {
+			return nil, err
+		}
+		if due != "" {
+			dueAt, err := parseTime(due)
+			if err != nil {
+				return nil, err
+			}
+			t.Due = &dueAt
+		}
+		if t.Priority, err = ParsePriority(priority); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(tags), &t.Tags); err != nil {
+			return nil, err
+		}
+
+		l = append(l, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	l.assignIDs()
+
+	return l, nil
+}
+
+// formatTime formats t to save it in the database. The zero time is saved as an
+// empty string
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339Nano)
+}
+
+// parseTime parses a time saved in the database with formatTime
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339Nano, s)
+}
@@ -0,0 +1,136 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrInvalidStore is returned when opening a kind of store that doesn't exist
+var ErrInvalidStore = errors.New("invalid store")
+
+// Store keeps a List between runs of the tool. Implementations must be safe to
+// use from several goroutines, and from several processes when they keep the list
+// outside of the process memory
+type Store interface {
+	// Load returns the saved list, which is empty if nothing was saved yet
+	Load() (List, error)
+	// Update changes the saved list with fn, making sure no other change happens at
+	// the same time. The list is saved only if fn doesn't return an error
+	Update(fn func(l *List) error) error
+	// Close releases the resources used by the store
+	Close() error
+}
+
+// The kinds of store that OpenStore can open
+const (
+	StoreJSON   = "json"
+	StoreSQLite = "sqlite"
+	StoreMemory = "memory"
+)
+
+// OpenStore opens the kind of store given by name, keeping the list in location.
+// The location is the file name for the JSON and SQLite stores and it's ignored by
+// the memory store
+func OpenStore(kind, location string) (Store, error) {
+	switch kind {
+	case StoreJSON:
+		return NewFileStore(location), nil
+	case StoreSQLite:
+		return OpenSQLiteStore(location)
+	case StoreMemory:
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidStore, kind)
+	}
+}
+
+// FileStore keeps the list as JSON in a file, the format used by the List Save
+// and Get methods
+type FileStore struct {
+	Filename string
+}
+
+// NewFileStore returns a store keeping the list in the JSON file filename
+func NewFileStore(filename string) *FileStore {
+	return &FileStore{Filename: filename}
+}
+
+// Load reads the list from the file. The file is always replaced atomically when
+// saved so it's read without locking it
+func (s *FileStore) Load() (List, error) {
+	l := List{}
+
+	if err := l.Get(s.Filename); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Update changes the list in the file while holding its lock
+func (s *FileStore) Update(fn func(l *List) error) error {
+	return Update(s.Filename, fn)
+}
+
+// Close does nothing as the file is only open while loading or updating the list
+func (s *FileStore) Close() error {
+	return nil
+}
+
+// MemoryStore keeps the list in memory, so it's lost when the process finishes.
+// It's useful in tests, or to try the tool without changing any saved list.
+// The zero value is an empty store ready to use
+type MemoryStore struct {
+	mu sync.Mutex
+	l  List
+}
+
+// NewMemoryStore returns an empty store keeping the list in memory
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Load returns a copy of the list, so changing it doesn't change the store
+func (s *MemoryStore) Load() (List, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.l.clone(), nil
+}
+
+// Update changes a copy of the list with fn and keeps it only if fn succeeds
+func (s *MemoryStore) Update(fn func(l *List) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l := s.l.clone()
+
+	if err := fn(&l); err != nil {
+		return err
+	}
+
+	s.l = l
+
+	return nil
+}
+
+// Close does nothing, the list is kept until the store is no longer used
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// clone returns a deep copy of the list, so changes to it, including to the tags of
+// its items, don't affect the original
+func (l *List) clone() List {
+	c := make(List, len(*l))
+	copy(c, *l)
+
+	for i := range c {
+		if c[i].Tags != nil {
+			c[i].Tags = append([]string{}, c[i].Tags...)
+		}
+	}
+
+	return c
+}
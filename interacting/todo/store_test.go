@@ -0,0 +1,292 @@
+package todo_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"pragprog.com/rggo/interacting/todo"
+)
+
+// TestStores runs the same tests against every kind of store
+func TestStores(t *testing.T) {
+	testCases := []struct {
+		kind string
+		file string
+	}{
+		{kind: todo.StoreJSON, file: "todo.json"},
+		{kind: todo.StoreSQLite, file: "todo.db"},
+		{kind: todo.StoreMemory},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.kind, func(t *testing.T) {
+			s, err := todo.OpenStore(tc.kind, filepath.Join(t.TempDir(), tc.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			t.Run("LoadEmpty", func(t *testing.T) {
+				l, err := s.Load()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(l) != 0 {
+					t.Errorf("Expected empty list, got %d items instead", len(l))
+				}
+			})
+
+			due := time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)
+
+			t.Run("Update", func(t *testing.T) {
+				err := s.Update(func(l *todo.List) error {
+					l.Add("Task 1", todo.WithPriority(todo.PriorityHigh), todo.WithDue(due),
+						todo.WithTags("work", "urgent"), todo.WithNotes("line 1\nline 2"))
+					l.Add("Task 2")
+					return l.Complete(2)
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				l, err := s.Load()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(l) != 2 {
+					t.Fatalf("Expected 2 items, got %d instead", len(l))
+				}
+
+				if l[0].Task != "Task 1" || l[0].Priority != todo.PriorityHigh || l[0].Due == nil || !l[0].Due.Equal(due) ||
+					!reflect.DeepEqual(l[0].Tags, []string{"work", "urgent"}) || l[0].Notes != "line 1\nline 2" {
+					t.Errorf("Item 1 not saved with its attributes, got %+v", l[0])
+				}
+
+				if !l[1].Done || l[1].CompletedAt.IsZero() {
+					t.Errorf("Item 2 should be completed, got %+v", l[1])
+				}
+			})
+
+			t.Run("UpdateError", func(t *testing.T) {
+				errFailed := errors.New("failed")
+
+				err := s.Update(func(l *todo.List) error {
+					l.Add("Not saved")
+					return errFailed
+				})
+				if !errors.Is(err, errFailed) {
+					t.Errorf("Expected error %q, got %q instead", errFailed, err)
+				}
+
+				l, err := s.Load()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(l) != 2 {
+					t.Errorf("Expected 2 items, got %d instead", len(l))
+				}
+			})
+
+			t.Run("LoadCopy", func(t *testing.T) {
+				l, err := s.Load()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				l[0].Tags[0] = "changed"
+				l.Delete(1)
+
+				l, err = s.Load()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(l) != 2 || l[0].Tags[0] != "work" {
+					t.Errorf("Changing the loaded list changed the store, got %+v", l)
+				}
+			})
+
+			t.Run("Concurrent", func(t *testing.T) {
+				const n = 10
+
+				var wg sync.WaitGroup
+
+				for i := 0; i < n; i++ {
+					wg.Add(1)
+
+					go func(i int) {
+						defer wg.Done()
+
+						err := s.Update(func(l *todo.List) error {
+							l.Add(fmt.Sprintf("Concurrent %d", i))
+							return nil
+						})
+						if err != nil {
+							t.Error(err)
+						}
+					}(i)
+				}
+
+				wg.Wait()
+
+				l, err := s.Load()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(l) != n+2 {
+					t.Errorf("Expected %d items, got %d instead", n+2, len(l))
+				}
+			})
+		})
+	}
+}
+
+// TestSQLiteStoreReopen tests the list is kept in the database file, named with
+// characters that have a special meaning in a URI
+func TestSQLiteStoreReopen(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "todo #1?a=%20.db")
+
+	s, err := todo.OpenSQLiteStore(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.Update(func(l *todo.List) error {
+		l.Add("Task")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = todo.OpenSQLiteStore(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	l, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(l) != 1 || l[0].Task != "Task" {
+		t.Errorf("Expected saved task, got %+v instead", l)
+	}
+
+	if _, err := os.Stat(filename); err != nil {
+		t.Errorf("Expected database file %q, got %q", filename, err)
+	}
+}
+
+// TestSQLiteStoreUpdateRows tests that updating the list writes only the rows of
+// the items changed, and keeps every item in its place
+func TestSQLiteStoreUpdateRows(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "todo.db")
+
+	s, err := todo.OpenSQLiteStore(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	err = s.Update(func(l *todo.List) error {
+		for i := 1; i <= 5; i++ {
+			l.Add(fmt.Sprintf("Task %d", i))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Count the rows written from now on. The driver is registered by the todo package
+	db, err := sql.Open("sqlite", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE writes (id TEXT);
+		CREATE TRIGGER count_inserts AFTER INSERT ON items BEGIN INSERT INTO writes VALUES (NEW.id); END;
+		CREATE TRIGGER count_updates AFTER UPDATE ON items BEGIN INSERT INTO writes VALUES (NEW.id); END;
+		CREATE TRIGGER count_deletes AFTER DELETE ON items BEGIN INSERT INTO writes VALUES (OLD.id); END`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleting the second item moves the three after it, besides the changes
+	err = s.Update(func(l *todo.List) error {
+		if err := l.Delete(2); err != nil {
+			return err
+		}
+		if err := l.Edit(1, todo.WithNotes("changed")); err != nil {
+			return err
+		}
+		l.Add("Task 6")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "  1: Task 1\n  2: Task 3\n  3: Task 4\n  4: Task 5\n  5: Task 6\n"
+	if l.String() != exp || l[0].Notes != "changed" {
+		t.Errorf("Expected %q, got %q instead", exp, l.String())
+	}
+
+	var writes, items int
+
+	if err := db.QueryRow("SELECT COUNT(DISTINCT id) FROM writes").Scan(&items); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the moved items are written twice
+	if err := db.QueryRow("SELECT COUNT(*) FROM writes").Scan(&writes); err != nil {
+		t.Fatal(err)
+	}
+
+	if items != 6 || writes != 9 {
+		t.Errorf("Expected 9 writes of 6 items, got %d writes of %d items instead", writes, items)
+	}
+
+	// Updating without changes writes nothing
+	if err := s.Update(func(l *todo.List) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.QueryRow("SELECT COUNT(*) FROM writes").Scan(&writes); err != nil {
+		t.Fatal(err)
+	}
+
+	if writes != 9 {
+		t.Errorf("Expected no writes without changes, got %d instead", writes-9)
+	}
+}
+
+// TestOpenStoreInvalid tests opening a kind of store that doesn't exist
+func TestOpenStoreInvalid(t *testing.T) {
+	if _, err := todo.OpenStore("csv", "todo.csv"); !errors.Is(err, todo.ErrInvalidStore) {
+		t.Errorf("Expected error %q, got %q instead", todo.ErrInvalidStore, err)
+	}
+}
@@ -5,33 +5,158 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 )
 
+var (
+	ErrInvalidPriority = errors.New("invalid priority")
+	ErrInvalidSortKey  = errors.New("invalid sort key")
+	ErrNotFound        = errors.New("not found")
+	ErrAmbiguousID     = errors.New("ambiguous ID prefix")
+)
+
+// Priority represents how important a ToDo item is
+type Priority int
+
+// The priority levels, from the lowest. PriorityNone is the zero value, used for
+// items without a priority such as those saved by previous versions
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+// priorityNames holds the name of each priority level, indexed by its value
+var priorityNames = []string{"none", "low", "medium", "high"}
+
+// String implements the fmt.Stringer interface returning the priority name
+func (p Priority) String() string {
+	if p < PriorityNone || int(p) >= len(priorityNames) {
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+
+	return priorityNames[p]
+}
+
+// ParsePriority returns the priority level with the given name, ignoring case
+func ParsePriority(name string) (Priority, error) {
+	for i, n := range priorityNames {
+		if strings.EqualFold(n, name) {
+			return Priority(i), nil
+		}
+	}
+
+	return PriorityNone, fmt.Errorf("%w: %q", ErrInvalidPriority, name)
+}
+
+// MarshalText implements the encoding.TextMarshaler interface so the priority is
+// saved in the JSON file by name
+func (p Priority) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface to read the
+// priority by name from the JSON file
+func (p *Priority) UnmarshalText(text []byte) error {
+	v, err := ParsePriority(string(text))
+	if err != nil {
+		return err
+	}
+
+	*p = v
+	return nil
+}
+
 // item struct represents a ToDo item
 // lowercase name means private to this package
+// The fields added after CompletedAt are optional, so files saved by previous
+// versions are still read, with their zero values. ID is assigned when reading
+// the items saved without it. Due is nil for items without a due date, so they are
+// saved without it
 type item struct {
+	ID          string `json:",omitempty"`
 	Task        string
 	Done        bool
 	CreatedAt   time.Time
 	CompletedAt time.Time
+	Priority    Priority   `json:",omitempty"`
+	Due         *time.Time `json:",omitempty"`
+	Tags        []string   `json:",omitempty"`
+	Notes       string     `json:",omitempty"`
+}
+
+// Option sets one of the optional attributes of an item, when adding or editing it
+type Option func(*item)
+
+// WithTask replaces the task of the item, used to edit it
+func WithTask(task string) Option {
+	return func(t *item) {
+		t.Task = task
+	}
+}
+
+// WithPriority sets the priority of the item
+func WithPriority(p Priority) Option {
+	return func(t *item) {
+		t.Priority = p
+	}
+}
+
+// WithDue sets the due date of the item. The zero time removes the due date
+func WithDue(due time.Time) Option {
+	return func(t *item) {
+		if due.IsZero() {
+			t.Due = nil
+			return
+		}
+
+		t.Due = &due
+	}
+}
+
+// WithTags replaces the tags of the item. Calling it without tags removes them
+func WithTags(tags ...string) Option {
+	return func(t *item) {
+		t.Tags = tags
+	}
+}
+
+// WithNotes sets the free-form notes of the item, which can have several lines
+func WithNotes(notes string) Option {
+	return func(t *item) {
+		t.Notes = notes
+	}
 }
 
 // Implementing the fmt.Stringer String() interface allows us to output a formatted list
+// Every item is included, so it's formatted the same as the entries selected by an
+// empty Filter
 func (l *List) String() string {
-	formatted := ""
+	return l.Filter(Filter{}).String()
+}
 
-	for k, t := range *l {
-		prefix := "  "
-		if t.Done {
-			prefix = "X "
-		}
+// attributes returns the optional attributes of the item that are set, formatted
+// to follow the task name in a single line. Notes are not included as they can
+// have several lines
+func (t item) attributes() string {
+	attrs := ""
 
-		// Adjust the item number k to print starting from 1 instead of 0
-		formatted += fmt.Sprintf("%s%d: %s\n", prefix, k+1, t.Task)
+	if t.Priority != PriorityNone {
+		attrs += fmt.Sprintf(" [%s]", t.Priority)
 	}
 
-	return formatted
+	if t.Due != nil {
+		attrs += fmt.Sprintf(" (due %s)", t.Due.Format("2006-01-02"))
+	}
+
+	for _, tag := range t.Tags {
+		attrs += " #" + tag
+	}
+
+	return attrs
 }
 
 // List represents a list of ToDo items
@@ -42,14 +167,20 @@ type List []item
 // Receiver uses naming convention of lowercase first character of receiving type name
 // Define the receiver as point to type when method modifies the content of the receiver
 // (otherwise it would change a copy of the list instead so changes discarded when method finishes)
-func (l *List) Add(task string) {
+// Any options given set the optional attributes of the new item
+func (l *List) Add(task string, opts ...Option) {
 	t := item{
+		ID:          l.newID(),
 		Task:        task,
 		Done:        false,
 		CreatedAt:   time.Now(),
 		CompletedAt: time.Time{},
 	}
 
+	for _, opt := range opts {
+		opt(&t)
+	}
+
 	// Dereference the pointer to List type in append call to access the underlying slice
 	*l = append(*l, t)
 }
@@ -71,6 +202,36 @@ func (l *List) Complete(i int) error {
 	return nil
 }
 
+// Reopen method marks a completed ToDo item as pending again, clearing its
+// completion time
+func (l *List) Reopen(i int) error {
+	ls := *l
+	if i <= 0 || i > len(ls) {
+		return fmt.Errorf("item %d does not exist", i)
+	}
+
+	// Adjusting index for 0 based index
+	ls[i-1].Done = false
+	ls[i-1].CompletedAt = time.Time{}
+
+	return nil
+}
+
+// Edit method changes the optional attributes of a ToDo item using the options given
+func (l *List) Edit(i int, opts ...Option) error {
+	ls := *l
+	if i <= 0 || i > len(ls) {
+		return fmt.Errorf("item %d does not exist", i)
+	}
+
+	// Adjusting index for 0 based index
+	for _, opt := range opts {
+		opt(&ls[i-1])
+	}
+
+	return nil
+}
+
 // Delete method deletes a ToDo item from the list
 func (l *List) Delete(i int) error {
 	ls := *l
@@ -86,13 +247,45 @@ func (l *List) Delete(i int) error {
 }
 
 // Save method encodes the List as JSON and saves it using the provided file name
+// The JSON is written to a temporary file in the same directory which then replaces
+// the original file, so readers never see a partially written list, even if the
+// process is interrupted while saving
 func (l *List) Save(filename string) error {
 	js, err := json.Marshal(l)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(filename, js, 0644)
+	// The temporary file must be in the same directory so renaming it is atomic
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	// Removing the temporary file fails harmlessly once it was renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(js); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	// Make sure the data is on disk before replacing the original file
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// CreateTemp creates the file readable only by the owner
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
 }
 
 // Get method opens the provided file name, decodes the JSON data and parses it into a List
@@ -109,5 +302,11 @@ func (l *List) Get(filename string) error {
 		return nil
 	}
 
-	return json.Unmarshal(file, l)
+	if err := json.Unmarshal(file, l); err != nil {
+		return err
+	}
+
+	l.assignIDs()
+
+	return nil
 }
@@ -6,8 +6,12 @@ package todo_test
 // access the exposed objects the same as a user would do.
 
 import (
+	"errors"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"pragprog.com/rggo/interacting/todo"
 )
@@ -46,6 +50,28 @@ func TestComplete(t *testing.T) {
 	}
 }
 
+// TestReopen tests the Reopen method of the List type
+func TestReopen(t *testing.T) {
+	l := todo.List{}
+	l.Add("New Task")
+
+	if err := l.Complete(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Reopen(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if l[0].Done || !l[0].CompletedAt.IsZero() {
+		t.Errorf("Expected pending task without completion time, got %+v instead.", l[0])
+	}
+
+	if err := l.Reopen(2); err == nil {
+		t.Errorf("Expected error reopening a task that doesn't exist.")
+	}
+}
+
 // TestDelete tests the Delete method of the List type
 func TestDelete(t *testing.T) {
 	l := todo.List{}
@@ -106,3 +132,171 @@ func TestSaveGet(t *testing.T) {
 		t.Errorf("Task %q should match %q task.", l1[0].Task, l2[0].Task)
 	}
 }
+
+// TestSaveOptional tests the optional attributes that are not set are not saved,
+// so the file keeps the shape of previous versions
+func TestSaveOptional(t *testing.T) {
+	l := todo.List{}
+	l.Add("No due date")
+	l.Add("Due date", todo.WithDue(time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)))
+
+	tf, err := os.CreateTemp("", "")
+	if err != nil {
+		t.Fatalf("Error creating temp file: %s", err)
+	}
+	tf.Close()
+	defer os.Remove(tf.Name())
+
+	if err := l.Save(tf.Name()); err != nil {
+		t.Fatalf("Error saving list to file: %s", err)
+	}
+
+	file, err := os.ReadFile(tf.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(string(file), `"Due"`); n != 1 {
+		t.Errorf("Expected 1 due date saved, got %d instead in %s", n, file)
+	}
+
+	got := todo.List{}
+	if err := got.Get(tf.Name()); err != nil {
+		t.Fatalf("Error getting list from file: %s", err)
+	}
+
+	if got[0].Due != nil || got[1].Due == nil || !got[1].Due.Equal(*l[1].Due) {
+		t.Errorf("Expected due dates %v and %v, got %v and %v instead", l[0].Due, l[1].Due, got[0].Due, got[1].Due)
+	}
+}
+
+// TestAddOptions tests the Add method sets the optional attributes of the item
+func TestAddOptions(t *testing.T) {
+	l := todo.List{}
+
+	due := time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)
+	l.Add("New Task",
+		todo.WithPriority(todo.PriorityHigh),
+		todo.WithDue(due),
+		todo.WithTags("work", "urgent"),
+		todo.WithNotes("line 1\nline 2"),
+	)
+
+	if l[0].Priority != todo.PriorityHigh {
+		t.Errorf("Expected priority %q, got %q instead.", todo.PriorityHigh, l[0].Priority)
+	}
+
+	if l[0].Due == nil || !l[0].Due.Equal(due) {
+		t.Errorf("Expected due date %s, got %v instead.", due, l[0].Due)
+	}
+
+	if !reflect.DeepEqual(l[0].Tags, []string{"work", "urgent"}) {
+		t.Errorf("Expected tags %q, got %q instead.", []string{"work", "urgent"}, l[0].Tags)
+	}
+
+	if l[0].Notes != "line 1\nline 2" {
+		t.Errorf("Expected notes %q, got %q instead.", "line 1\nline 2", l[0].Notes)
+	}
+
+	exp := "  1: New Task [high] (due 2026-10-20) #work #urgent\n"
+	if l.String() != exp {
+		t.Errorf("Expected %q, got %q instead.", exp, l.String())
+	}
+}
+
+// TestEdit tests the Edit method changes only the given attributes
+func TestEdit(t *testing.T) {
+	l := todo.List{}
+
+	l.Add("New Task", todo.WithPriority(todo.PriorityLow), todo.WithTags("home"))
+
+	if err := l.Edit(1, todo.WithPriority(todo.PriorityMedium), todo.WithNotes("note")); err != nil {
+		t.Fatal(err)
+	}
+
+	if l[0].Task != "New Task" {
+		t.Errorf("Expected task %q, got %q instead.", "New Task", l[0].Task)
+	}
+
+	if err := l.Edit(1, todo.WithTask("Renamed Task")); err != nil {
+		t.Fatal(err)
+	}
+
+	if l[0].Task != "Renamed Task" {
+		t.Errorf("Expected task %q, got %q instead.", "Renamed Task", l[0].Task)
+	}
+
+	if l[0].Priority != todo.PriorityMedium {
+		t.Errorf("Expected priority %q, got %q instead.", todo.PriorityMedium, l[0].Priority)
+	}
+
+	if l[0].Notes != "note" || len(l[0].Tags) != 1 {
+		t.Errorf("Expected notes and tags to be set, got %q and %q instead.", l[0].Notes, l[0].Tags)
+	}
+
+	if err := l.Edit(2, todo.WithNotes("note")); err == nil {
+		t.Errorf("Expected error editing missing item.")
+	}
+}
+
+// TestParsePriority tests parsing priority names
+func TestParsePriority(t *testing.T) {
+	p, err := todo.ParsePriority("HIGH")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p != todo.PriorityHigh {
+		t.Errorf("Expected %q, got %q instead.", todo.PriorityHigh, p)
+	}
+
+	if _, err := todo.ParsePriority("urgent"); !errors.Is(err, todo.ErrInvalidPriority) {
+		t.Errorf("Expected error %q, got %q instead.", todo.ErrInvalidPriority, err)
+	}
+}
+
+// TestGetPreviousVersion tests a file saved before the optional attributes existed
+// can still be read
+func TestGetPreviousVersion(t *testing.T) {
+	l := todo.List{}
+
+	tf, err := os.CreateTemp("", "")
+	if err != nil {
+		t.Fatalf("Error creating temp file: %s", err)
+	}
+	defer os.Remove(tf.Name())
+
+	old := `[{"Task":"Old Task","Done":true,"CreatedAt":"2022-09-25T10:00:00Z","CompletedAt":"2022-09-26T10:00:00Z"}]`
+	if _, err := tf.WriteString(old); err != nil {
+		t.Fatal(err)
+	}
+	tf.Close()
+
+	if err := l.Get(tf.Name()); err != nil {
+		t.Fatalf("Error getting list from file: %s", err)
+	}
+
+	if l[0].Task != "Old Task" || !l[0].Done {
+		t.Errorf("Expected done task %q, got %+v instead.", "Old Task", l[0])
+	}
+
+	if l[0].Priority != todo.PriorityNone || l[0].Due != nil || l[0].Tags != nil || l[0].Notes != "" {
+		t.Errorf("Expected no optional attributes, got %+v instead.", l[0])
+	}
+
+	// Saving and reading again keeps the priority by name
+	l.Edit(1, todo.WithPriority(todo.PriorityLow))
+
+	if err := l.Save(tf.Name()); err != nil {
+		t.Fatal(err)
+	}
+
+	l2 := todo.List{}
+	if err := l2.Get(tf.Name()); err != nil {
+		t.Fatal(err)
+	}
+
+	if l2[0].Priority != todo.PriorityLow {
+		t.Errorf("Expected priority %q, got %q instead.", todo.PriorityLow, l2[0].Priority)
+	}
+}
@@ -0,0 +1,97 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// timeFormat is the layout used to show the times of an item
+const timeFormat = "2006-01-02 15:04"
+
+// Verbose formats the entries like String, followed for each item by its ID, creation
+// and completion times, the time it took to complete, its due date and its notes.
+// Times are also shown relative to now, such as "3 days ago"
+func (e Entries) Verbose(now time.Time) string {
+	formatted := ""
+
+	// Details are indented to line up with the task name
+	indent := "     "
+
+	for _, t := range e {
+		prefix := "  "
+		if t.Done {
+			prefix = "X "
+		}
+
+		formatted += fmt.Sprintf("%s%d: %s%s\n", prefix, t.Number, t.Task, t.attributes())
+
+		formatted += fmt.Sprintf("%sID:        %s\n", indent, t.ID)
+
+		formatted += fmt.Sprintf("%sCreated:   %s (%s)\n", indent,
+			t.CreatedAt.Local().Format(timeFormat), relativeTime(t.CreatedAt, now))
+
+		if t.Done {
+			formatted += fmt.Sprintf("%sCompleted: %s (%s), took %s\n", indent,
+				t.CompletedAt.Local().Format(timeFormat), relativeTime(t.CompletedAt, now),
+				humanDuration(t.CompletedAt.Sub(t.CreatedAt)))
+		}
+
+		if t.Due != nil {
+			formatted += fmt.Sprintf("%sDue:       %s (%s)\n", indent,
+				t.Due.Local().Format("2006-01-02"), relativeTime(*t.Due, now))
+		}
+
+		if t.Notes != "" {
+			// Indent every line of the notes under the first one
+			notes := strings.ReplaceAll(strings.TrimRight(t.Notes, "\n"), "\n", "\n"+indent+"           ")
+			formatted += fmt.Sprintf("%sNotes:     %s\n", indent, notes)
+		}
+	}
+
+	return formatted
+}
+
+// relativeTime describes t relative to now, such as "3 days ago" or "in 2 hours"
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+
+	if d > -time.Minute && d < time.Minute {
+		return "just now"
+	}
+
+	if d < 0 {
+		return "in " + humanDuration(-d)
+	}
+
+	return humanDuration(d) + " ago"
+}
+
+// humanDuration describes the duration using its largest unit, from minutes to
+// years, such as "1 hour" or "3 days"
+func humanDuration(d time.Duration) string {
+	day := 24 * time.Hour
+
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{365 * day, "year"},
+		{30 * day, "month"},
+		{day, "day"},
+		{time.Hour, "hour"},
+		{time.Minute, "minute"},
+	}
+
+	for _, u := range units {
+		if d >= u.size {
+			n := int(d / u.size)
+			if n == 1 {
+				return fmt.Sprintf("1 %s", u.name)
+			}
+			return fmt.Sprintf("%d %ss", n, u.name)
+		}
+	}
+
+	return "less than a minute"
+}
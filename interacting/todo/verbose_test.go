@@ -0,0 +1,49 @@
+package todo_test
+
+import (
+	"testing"
+	"time"
+
+	"pragprog.com/rggo/interacting/todo"
+)
+
+// TestVerbose tests the verbose format shows the times and notes of each item
+func TestVerbose(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+
+	l := todo.List{}
+	l.Add("Task 1", todo.WithNotes("line 1\nline 2\n"))
+	l.Add("Task 2", todo.WithDue(time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)))
+	l.Add("Task 3")
+
+	l[0].CreatedAt = now.Add(-3 * 24 * time.Hour)
+	l.Complete(1)
+	l[0].CompletedAt = now.Add(-90 * time.Minute)
+
+	l[1].CreatedAt = now.Add(-time.Hour)
+	l[2].CreatedAt = now.Add(-30 * time.Second)
+
+	// IDs are random, so use known ones
+	l[0].ID, l[1].ID, l[2].ID = "kkkkkkkkkkkk", "llllllllllll", "mmmmmmmmmmmm"
+
+	exp := `X 1: Task 1
+     ID:        kkkkkkkkkkkk
+     Created:   2026-10-13 12:00 (3 days ago)
+     Completed: 2026-10-16 10:30 (1 hour ago), took 2 days
+     Notes:     line 1
+                line 2
+  2: Task 2 (due 2026-10-20)
+     ID:        llllllllllll
+     Created:   2026-10-16 11:00 (1 hour ago)
+     Due:       2026-10-20 (in 3 days)
+  3: Task 3
+     ID:        mmmmmmmmmmmm
+     Created:   2026-10-16 11:59 (just now)
+`
+
+	res := l.Filter(todo.Filter{}).Verbose(now)
+
+	if res != exp {
+		t.Errorf("Expected %q, got %q instead.", exp, res)
+	}
+}